@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/app"
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/tenant"
+)
+
+const apiKeyUsage = `usage:
+  apikey create -name NAME [-tenant TENANT] -scopes read,track,admin
+  apikey list
+  apikey revoke -id ID`
+
+// runAPIKeyCommand manages the API keys and returns the exit code.
+func runAPIKeyCommand(a *app.App, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, apiKeyUsage)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cmd := flag.NewFlagSet("apikey "+args[0], flag.ContinueOnError)
+
+	var err error
+	switch args[0] {
+	case "create":
+		name := cmd.String("name", "", "Client the key is issued to")
+		tenantID := cmd.String("tenant", tenant.Default, "Tenant the key gives access to")
+		scopes := cmd.String("scopes", "", "Comma-separated scopes: read, track, admin")
+		if cmd.Parse(args[1:]) != nil {
+			return 2
+		}
+		err = createAPIKey(ctx, a, *name, *tenantID, *scopes)
+	case "list":
+		if cmd.Parse(args[1:]) != nil {
+			return 2
+		}
+		err = listAPIKeys(ctx, a)
+	case "revoke":
+		id := cmd.Int("id", 0, "ID of the key to revoke")
+		if cmd.Parse(args[1:]) != nil {
+			return 2
+		}
+		err = a.RevokeAPIKey(ctx, *id)
+	default:
+		fmt.Fprintln(os.Stderr, apiKeyUsage)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "apikey %s: %s\n", args[0], err.Error())
+		for field, msg := range apperror.FieldsOf(err) {
+			fmt.Fprintf(os.Stderr, "  %s: %s\n", field, msg)
+		}
+		return 1
+	}
+	return 0
+}
+
+func createAPIKey(ctx context.Context, a *app.App, name, tenantID, scopes string) error {
+	var scopeList []string
+	for _, scope := range strings.Split(scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopeList = append(scopeList, scope)
+		}
+	}
+
+	apiKey, key, err := a.CreateAPIKey(ctx, name, tenantID, scopeList)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created API key %d for %s in tenant %s with scopes %s\n", apiKey.ID, apiKey.Name, apiKey.Tenant,
+		strings.Join(apiKey.Scopes, ","))
+	fmt.Println("store the key now, it is not shown again:")
+	fmt.Println(key)
+
+	return nil
+}
+
+func listAPIKeys(ctx context.Context, a *app.App) error {
+	keys, err := a.ListAPIKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tTENANT\tSCOPES\tCREATED\tREVOKED")
+	for _, key := range keys {
+		revoked := "-"
+		if key.RevokedAt != nil {
+			revoked = key.RevokedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Tenant, strings.Join(key.Scopes, ","),
+			key.CreatedAt.Format(time.RFC3339), revoked)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/otus-murashko/banners-rotation/internal/config"
+)
+
+const configUsage = `usage:
+  config check`
+
+// runConfigCommand validates the config file with the environment overrides
+// and returns the exit code.
+func runConfigCommand(args []string) int {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprintln(os.Stderr, configUsage)
+		return 2
+	}
+
+	cmd := flag.NewFlagSet("config check", flag.ContinueOnError)
+	if cmd.Parse(args[1:]) != nil {
+		return 2
+	}
+
+	if _, err := config.GetBannersConfig(configFile); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	fmt.Println("config is valid")
+	return 0
+}
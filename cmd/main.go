@@ -3,52 +3,169 @@ package main
 import (
 	"context"
 	"flag"
-	"log"
+	"fmt"
+	"log/slog"
+	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/otus-murashko/banners-rotation/internal/app"
 	"github.com/otus-murashko/banners-rotation/internal/config"
+	"github.com/otus-murashko/banners-rotation/internal/fraud"
+	"github.com/otus-murashko/banners-rotation/internal/logger"
+	"github.com/otus-murashko/banners-rotation/internal/metrics"
+	"github.com/otus-murashko/banners-rotation/internal/segment"
+	internalgrpc "github.com/otus-murashko/banners-rotation/internal/server/grpc"
 	internalhttp "github.com/otus-murashko/banners-rotation/internal/server/http"
+	"github.com/otus-murashko/banners-rotation/internal/storage/instrumented"
+	"github.com/otus-murashko/banners-rotation/internal/tracing"
 )
 
 var configFile string
 
 func init() {
-	flag.StringVar(&configFile, "conf", "./../configs/config.yaml", "Path to configuration file")
+	flag.StringVar(&configFile, "conf", "./../configs/config.yaml",
+		"Path to configuration file, empty to configure by "+config.EnvPrefix+"_* environment variables only")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-conf FILE] [apikey ... | config check]\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintln(flag.CommandLine.Output(), apiKeyUsage)
+		fmt.Fprintln(flag.CommandLine.Output(), configUsage)
+	}
 }
 
 func main() {
 	flag.Parse()
 
-	config := config.GetBannersConfig(configFile)
-	storage := getStorage(config.Database)
-	if err := storage.Connect(); err != nil {
-		log.Println(err.Error())
+	if flag.Arg(0) == "config" {
+		os.Exit(runConfigCommand(flag.Args()[1:]))
+	}
+
+	config, err := config.GetBannersConfig(configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	logger, err := logger.New(config.Logger, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create logger: %s \n", err.Error())
+		os.Exit(1)
 	}
-	bannerApp := app.New(storage)
-	server := internalhttp.NewServer(bannerApp, config.Server)
+	slog.SetDefault(logger)
 
-	ctx, cancel := signal.NotifyContext(context.Background(),
-		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
+	// SIGHUP reloads the config once the servers are up
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+
+	shutdownTracing, err := tracing.Setup(ctx, config.Tracing)
+	if err != nil {
+		fatal("failed to set up tracing", err)
+	}
+
+	storage := instrumented.New(getStorage(config.Database))
+	if err := connectStorage(ctx, storage, config.Database.Connect); err != nil {
+		fatal("failed to connect to storage", err)
+	}
+	bannerApp, err := app.New(storage, segment.NewResolver(config.Segments), fraud.NewChain(config.Fraud),
+		config.Rotation)
+	if err != nil {
+		fatal("failed to create banner app", err)
+	}
+
+	if flag.Arg(0) == "apikey" {
+		code := runAPIKeyCommand(bannerApp, flag.Args()[1:])
+		storage.Close()
+		os.Exit(code)
+	}
+
+	server, err := internalhttp.NewServer(bannerApp, config.Server)
+	if err != nil {
+		fatal("failed to create http server", err)
+	}
+	grpcServer := internalgrpc.NewServer(bannerApp, config.GRPC)
+	metricsServer := metrics.NewServer(config.Metrics)
+
 	go func() {
 		<-ctx.Done()
 
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
-		defer cancel()
+		stopServers(time.Second*3, map[string]stopper{
+			"grpc":    grpcServer,
+			"http":    server,
+			"metrics": metricsServer,
+		})
+	}()
 
-		if err := server.Stop(ctx); err != nil {
-			log.Printf("failed to stop http server: %s \n", err.Error())
+	go func() {
+		running := config
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-reload:
+				running = reloadConfig(running, bannerApp, server, grpcServer)
+			}
 		}
+	}()
 
+	go func() {
+		if err := grpcServer.Start(ctx); err != nil {
+			slog.Error("failed to start grpc server", "error", err)
+			cancel()
+		}
 	}()
 
-	log.Println("banner server is running...")
+	go func() {
+		if err := metricsServer.Start(ctx); err != nil {
+			slog.Error("failed to start metrics server", "error", err)
+			cancel()
+		}
+	}()
+
+	slog.Info("banner server is running")
 	if err := server.Start(ctx); err != nil {
-		log.Printf("failed to start http server: %s \n", err.Error())
+		slog.Error("failed to start http server", "error", err)
+	}
+
+	// flush the spans of the last requests
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer flushCancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
 	}
 
 }
+
+type stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// stopServers stops the servers at once, each within its own timeout, so a
+// slow one does not use up the time of the others.
+func stopServers(timeout time.Duration, servers map[string]stopper) {
+	var wg sync.WaitGroup
+	for name, server := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := server.Stop(ctx); err != nil {
+				slog.Error("failed to stop "+name+" server", "error", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowServer takes the whole timeout to stop and records whether its
+// context was already done when it was asked to stop.
+type slowServer struct {
+	mu      sync.Mutex
+	expired bool
+	stopped bool
+}
+
+func (s *slowServer) Stop(ctx context.Context) error {
+	expired := ctx.Err() != nil
+	<-ctx.Done()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expired, s.stopped = expired, true
+	return nil
+}
+
+func TestStopServers(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	servers := map[string]*slowServer{"grpc": {}, "http": {}, "metrics": {}}
+
+	stoppers := make(map[string]stopper, len(servers))
+	for name, server := range servers {
+		stoppers[name] = server
+	}
+
+	start := time.Now()
+	stopServers(timeout, stoppers)
+	elapsed := time.Since(start)
+
+	for name, server := range servers {
+		if !server.stopped {
+			t.Errorf("%s server was not stopped", name)
+		}
+		if server.expired {
+			t.Errorf("%s server was stopped with an expired context", name)
+		}
+	}
+	if elapsed >= 2*timeout {
+		t.Errorf("stopping took %s, want the servers stopped at once within %s", elapsed, timeout)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"log/slog"
+	"reflect"
+
+	"github.com/otus-murashko/banners-rotation/internal/app"
+	"github.com/otus-murashko/banners-rotation/internal/config"
+	"github.com/otus-murashko/banners-rotation/internal/logger"
+	internalgrpc "github.com/otus-murashko/banners-rotation/internal/server/grpc"
+	internalhttp "github.com/otus-murashko/banners-rotation/internal/server/http"
+)
+
+// reloadConfig re-reads the config file and applies the settings that are
+// safe to change at runtime: the log level, the rotation, the rate limits and
+// the content of the TLS certificate files.
+// It returns the config in effect, the running one when the new config is
+// invalid.
+func reloadConfig(running config.Config, a *app.App, server *internalhttp.Server,
+	grpcServer *internalgrpc.Server,
+) config.Config {
+	conf, err := config.GetBannersConfig(configFile)
+	if err != nil {
+		slog.Error("failed to reload config, keeping the running one", "error", err)
+		return running
+	}
+
+	if err := a.ApplyRotation(conf.Rotation); err != nil {
+		slog.Error("failed to reload config, keeping the running one", "error", err)
+		return running
+	}
+	if err := logger.SetLevel(conf.Logger.Level); err != nil {
+		slog.Error("failed to reload log level", "error", err)
+	}
+	server.SetRateLimit(conf.Server.RateLimit)
+	grpcServer.SetRateLimit(conf.GRPC.RateLimit)
+	if err := server.ReloadTLS(); err != nil {
+		slog.Error("failed to reload TLS certificate, keeping the loaded one", "error", err)
+	}
+
+	applied := withReloadable(running, conf)
+	if !reflect.DeepEqual(applied, conf) {
+		slog.Warn("config has changes that take effect after restart only")
+	}
+	slog.Info("config reloaded")
+
+	return applied
+}
+
+// withReloadable returns running with the settings applied by reloadConfig
+// taken from conf.
+func withReloadable(running, conf config.Config) config.Config {
+	running.Logger.Level = conf.Logger.Level
+	running.Rotation = conf.Rotation
+	running.Server.RateLimit = conf.Server.RateLimit
+	running.GRPC.RateLimit = conf.GRPC.RateLimit
+	return running
+}
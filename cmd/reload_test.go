@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/app"
+	"github.com/otus-murashko/banners-rotation/internal/config"
+	"github.com/otus-murashko/banners-rotation/internal/fraud"
+	"github.com/otus-murashko/banners-rotation/internal/logger"
+	"github.com/otus-murashko/banners-rotation/internal/segment"
+	internalgrpc "github.com/otus-murashko/banners-rotation/internal/server/grpc"
+	internalhttp "github.com/otus-murashko/banners-rotation/internal/server/http"
+)
+
+func TestWithReloadable(t *testing.T) {
+	running := config.Default()
+
+	conf := config.Default()
+	conf.Logger.Level = "debug"
+	conf.Rotation.Strategy = "thompson"
+	conf.Server.RateLimit.PerIP = config.Limit{Rate: 5, Burst: 10}
+	conf.GRPC.RateLimit.PerKey = config.Limit{Rate: 7}
+	conf.Database.Host = "db.example.com"
+	conf.Server.Port = 9090
+
+	got := withReloadable(running, conf)
+
+	want := config.Default()
+	want.Logger.Level = "debug"
+	want.Rotation.Strategy = "thompson"
+	want.Server.RateLimit.PerIP = config.Limit{Rate: 5, Burst: 10}
+	want.GRPC.RateLimit.PerKey = config.Limit{Rate: 7}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("withReloadable() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		want      func(running config.Config) config.Config
+		wantDebug bool
+	}{
+		{
+			name: "reloadable fields",
+			file: `
+logger:
+  level: debug
+rotation:
+  strategy: thompson
+server:
+  port: 9090
+  rateLimit:
+    perIP: {rate: 5, burst: 10}
+db:
+  host: db.example.com
+`,
+			want: func(running config.Config) config.Config {
+				running.Logger.Level = "debug"
+				running.Rotation.Strategy = "thompson"
+				running.Server.RateLimit.PerIP = config.Limit{Rate: 5, Burst: 10}
+				return running
+			},
+			wantDebug: true,
+		},
+		{
+			name: "invalid config",
+			file: `
+logger:
+  level: debug
+rotation:
+  strategy: greedy
+`,
+			want: func(running config.Config) config.Config { return running },
+		},
+		{
+			name: "unknown field",
+			file: `
+logger:
+  lvl: debug
+`,
+			want: func(running config.Config) config.Config { return running },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			running := config.Default()
+			running.Logger.Level = "info"
+
+			log, err := logger.New(running.Logger, io.Discard)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := app.New(nil, segment.NewResolver(running.Segments), fraud.NewChain(running.Fraud),
+				running.Rotation)
+			if err != nil {
+				t.Fatal(err)
+			}
+			server, err := internalhttp.NewServer(a, running.Server)
+			if err != nil {
+				t.Fatal(err)
+			}
+			grpcServer := internalgrpc.NewServer(a, running.GRPC)
+
+			file := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(file, []byte(tt.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			previous := configFile
+			configFile = file
+			t.Cleanup(func() { configFile = previous })
+
+			got := reloadConfig(running, a, server, grpcServer)
+
+			if want := tt.want(running); !reflect.DeepEqual(got, want) {
+				t.Errorf("reloadConfig() = %+v, want %+v", got, want)
+			}
+			if debug := log.Enabled(context.Background(), slog.LevelDebug); debug != tt.wantDebug {
+				t.Errorf("debug logged = %v, want %v", debug, tt.wantDebug)
+			}
+		})
+	}
+}
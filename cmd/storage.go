@@ -1,6 +1,10 @@
 package main
 
 import (
+	"context"
+	"log/slog"
+	"time"
+
 	"github.com/otus-murashko/banners-rotation/internal/config"
 	"github.com/otus-murashko/banners-rotation/internal/storage"
 	"github.com/otus-murashko/banners-rotation/internal/storage/psql"
@@ -19,3 +23,53 @@ func getStorage(config config.DBConfig) storage.Storage {
 		DBName:   config.DBName,
 	})
 }
+
+const (
+	defaultConnectBackoff    = time.Second
+	defaultConnectMaxBackoff = 30 * time.Second
+	connectAttemptTimeout    = 5 * time.Second
+)
+
+// connectStorage opens the storage and pings it until it answers, so the
+// service does not start serving without a database.
+func connectStorage(ctx context.Context, s storage.Storage, retry config.Retry) error {
+	if err := s.Connect(); err != nil {
+		return err
+	}
+
+	attempts := max(retry.Attempts, 1)
+	backoff := retry.Backoff
+	if backoff <= 0 {
+		backoff = defaultConnectBackoff
+	}
+	maxBackoff := retry.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultConnectMaxBackoff
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, connectAttemptTimeout)
+		err = s.Ping(pingCtx)
+		cancel()
+		if err == nil || attempt == attempts {
+			break
+		}
+
+		slog.WarnContext(ctx, "storage is not reachable",
+			"attempt", attempt, "attempts", attempts, "retry_in", backoff, "error", err)
+
+		select {
+		case <-ctx.Done():
+			s.Close()
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+
+	if err != nil {
+		s.Close()
+	}
+	return err
+}
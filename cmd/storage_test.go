@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/config"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+// pingStorage answers the pings after failures of them and records whether
+// it was closed.
+type pingStorage struct {
+	storage.Storage
+	connectErr error
+	failures   int
+	pings      int
+	closed     bool
+}
+
+func (s *pingStorage) Connect() error { return s.connectErr }
+
+func (s *pingStorage) Close() error {
+	s.closed = true
+	return nil
+}
+
+func (s *pingStorage) Ping(context.Context) error {
+	s.pings++
+	if s.pings <= s.failures {
+		return apperror.Unavailable("database is unavailable")
+	}
+	return nil
+}
+
+func TestConnectStorage(t *testing.T) {
+	retry := config.Retry{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+	connectErr := errors.New("invalid connection string")
+
+	tests := []struct {
+		name       string
+		storage    *pingStorage
+		retry      config.Retry
+		wantErr    error
+		wantPings  int
+		wantClosed bool
+	}{
+		{name: "reachable", storage: &pingStorage{}, retry: retry, wantPings: 1},
+		{name: "reachable after retries", storage: &pingStorage{failures: 2}, retry: retry, wantPings: 3},
+		{
+			name:       "unreachable",
+			storage:    &pingStorage{failures: 3},
+			retry:      retry,
+			wantErr:    apperror.Unavailable("database is unavailable"),
+			wantPings:  3,
+			wantClosed: true,
+		},
+		{
+			name:       "one attempt without retries",
+			storage:    &pingStorage{failures: 1},
+			wantErr:    apperror.Unavailable("database is unavailable"),
+			wantPings:  1,
+			wantClosed: true,
+		},
+		{name: "connect error", storage: &pingStorage{connectErr: connectErr}, retry: retry, wantErr: connectErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := connectStorage(context.Background(), tt.storage, tt.retry)
+
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && (err == nil || err.Error() != tt.wantErr.Error()) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.storage.pings != tt.wantPings {
+				t.Errorf("pings = %d, want %d", tt.storage.pings, tt.wantPings)
+			}
+			if tt.storage.closed != tt.wantClosed {
+				t.Errorf("closed = %v, want %v", tt.storage.closed, tt.wantClosed)
+			}
+		})
+	}
+}
+
+func TestConnectStorageCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := &pingStorage{failures: 1}
+
+	err := connectStorage(ctx, s, config.Retry{Attempts: 3, Backoff: time.Hour})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want %v", err, context.Canceled)
+	}
+	if !s.closed {
+		t.Error("storage is not closed")
+	}
+}
@@ -2,38 +2,85 @@ package app
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"time"
 
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/auth"
 	"github.com/otus-murashko/banners-rotation/internal/banner"
+	"github.com/otus-murashko/banners-rotation/internal/config"
+	"github.com/otus-murashko/banners-rotation/internal/experiment"
+	"github.com/otus-murashko/banners-rotation/internal/fraud"
+	"github.com/otus-murashko/banners-rotation/internal/metrics"
+	"github.com/otus-murashko/banners-rotation/internal/segment"
 	"github.com/otus-murashko/banners-rotation/internal/storage"
+	"github.com/otus-murashko/banners-rotation/internal/tenant"
+	"github.com/otus-murashko/banners-rotation/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Application interface {
+	Ready(ctx context.Context) error
 	GetBannersBySlot(ctx context.Context, slotID int) ([]int, error)
 	GetBannersStat(ctx context.Context, slotID int, groupID int, bannerIDs []int) ([]storage.Statistic, error)
-	AddBannerToSlot(ctx context.Context, bannerID int, slotID int) error
+	AddBannerToSlot(ctx context.Context, rotation storage.Rotation) error
 	DeleteBannerFromSlot(ctx context.Context, bannerID int, slotID int) error
-	CreateBanner(ctx context.Context, desc string) (int, error)
+	CreateBanner(ctx context.Context, banner storage.Banner) (int, error)
 	CreateSlot(ctx context.Context, desc string) (int, error)
 	CreateGroup(ctx context.Context, desc string) (int, error)
-	GetBannerRotation(ctx context.Context, slotID, sGroupID int) (storage.Banner, error)
-	UpdateShowStat(ctx context.Context, stat storage.Statistic) error
-	UpdateClickStat(ctx context.Context, stat storage.Statistic) error
+	GetBannerRotation(ctx context.Context, slotID, sGroupID int, attrs segment.Attributes) (storage.BannerRotation, error)
+	GetBannerRotations(ctx context.Context, slotIDs []int, sGroupID int, attrs segment.Attributes, unique bool) (map[int]storage.BannerRotation, error)
+	UpdateClickStat(ctx context.Context, stat storage.Statistic, client storage.ClientInfo) error
+	RecordEvents(ctx context.Context, events []storage.Event) error
+	CreateExperiment(ctx context.Context, exp storage.Experiment) (int, error)
+	GetExperimentReport(ctx context.Context, experimentID int) ([]experiment.ArmReport, error)
+	TrackConversion(ctx context.Context, conv storage.Conversion) error
+	GetRevenueReport(ctx context.Context, slotID int) ([]banner.SlotRevenue, error)
+	ClaimIdempotencyKey(ctx context.Context, key, fingerprint string, window, lease time.Duration) (storage.IdempotentResponse, bool, error)
+	SaveIdempotentResponse(ctx context.Context, key string, resp storage.IdempotentResponse) error
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+	Authenticate(ctx context.Context, key string, scope auth.Scope) (storage.APIKey, error)
 }
 
+const defaultAttributionWindow = 24 * time.Hour
+
 type BannerSelector interface {
-	GetBanner(ctx context.Context, slotID, sGroupID int) (storage.Banner, error)
+	GetBanner(ctx context.Context, slotID, sGroupID int, attrs segment.Attributes) (storage.Banner, error)
+	Pick(candidates []storage.SlotBanner) (storage.SlotBanner, bool)
 }
 
 type App struct {
-	storage storage.Storage
-	bs      BannerSelector
+	storage      storage.Storage
+	rotation     *atomic.Pointer[rotation]
+	segments     segment.Resolver
+	clickFilters fraud.Chain
 }
 
-func New(storage storage.Storage) *App {
-	return &App{
-		storage: storage,
-		bs:      banner.NewBannerBanditSelector(storage),
+func New(storage storage.Storage, segments segment.Resolver, clickFilters fraud.Chain,
+	conf config.Rotation,
+) (*App, error) {
+	r, err := newRotation(storage, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	app := &App{
+		storage:      storage,
+		rotation:     &atomic.Pointer[rotation]{},
+		segments:     segments,
+		clickFilters: clickFilters,
 	}
+	app.rotation.Store(r)
+
+	return app, nil
+}
+
+// Ready reports whether the dependencies of the service are reachable.
+func (a App) Ready(ctx context.Context) error {
+	return a.storage.Ping(ctx)
 }
 
 func (a App) GetBannersBySlot(ctx context.Context, slotID int) ([]int, error) {
@@ -44,33 +91,550 @@ func (a App) GetBannersStat(ctx context.Context, slotID int, groupID int, banner
 	return a.storage.GetBannersStat(ctx, slotID, groupID, bannerIDs)
 }
 
-func (a App) AddBannerToSlot(ctx context.Context, bannerID int, slotID int) error {
-	return a.storage.AddBannerToSlot(ctx, bannerID, slotID)
+func (a App) AddBannerToSlot(ctx context.Context, rotation storage.Rotation) error {
+	if err := a.validateRotation(ctx, rotation); err != nil {
+		return err
+	}
+
+	return a.storage.AddBannerToSlot(ctx, rotation.BannerID, rotation.SlotID, rotation.Targeting)
 }
 
 func (a App) DeleteBannerFromSlot(ctx context.Context, bannerID int, slotID int) error {
+	if err := a.validateRotation(ctx, storage.Rotation{BannerID: bannerID, SlotID: slotID}); err != nil {
+		return err
+	}
+
 	return a.storage.DeleteBannerFromSlot(ctx, bannerID, slotID)
 }
 
-func (a App) CreateBanner(ctx context.Context, desc string) (int, error) {
-	return a.storage.CreateBanner(ctx, desc)
+func (a App) CreateBanner(ctx context.Context, b storage.Banner) (int, error) {
+	if err := validateBanner(b); err != nil {
+		return 0, err
+	}
+
+	if b.Pricing == "" {
+		b.Pricing = storage.PricingCPM
+	}
+
+	return a.storage.CreateBanner(ctx, b)
 }
 
 func (a App) CreateSlot(ctx context.Context, desc string) (int, error) {
+	if err := validateDescr(desc); err != nil {
+		return 0, err
+	}
+
 	return a.storage.CreateSlot(ctx, desc)
 }
 
 func (a App) CreateGroup(ctx context.Context, desc string) (int, error) {
+	if err := validateDescr(desc); err != nil {
+		return 0, err
+	}
+
 	return a.storage.CreateGroup(ctx, desc)
 }
 
-func (a App) GetBannerRotation(ctx context.Context, slotID, sGroupID int) (storage.Banner, error) {
-	return a.bs.GetBanner(ctx, slotID, sGroupID)
+// GetBannerRotation selects a banner for the slot. When sGroupID is zero the
+// social group is resolved from the request attributes.
+func (a App) GetBannerRotation(ctx context.Context, slotID, sGroupID int, attrs segment.Attributes) (storage.BannerRotation, error) {
+	if err := validateRotationRequest(slotID, sGroupID); err != nil {
+		return storage.BannerRotation{}, err
+	}
+
+	sGroupID, err := a.resolveGroup(ctx, sGroupID, attrs)
+	if err != nil {
+		return storage.BannerRotation{}, err
+	}
+
+	exp, err := a.storage.GetSlotExperiment(ctx, slotID)
+	if err != nil {
+		return storage.BannerRotation{}, err
+	}
+
+	r := a.rotation.Load()
+	bs, strategy := r.forSlot(slotID)
+	var arm storage.ExperimentArm
+	if exp.ID != 0 && len(exp.Arms) > 0 {
+		arm = experiment.PickArm(exp.Arms)
+		strategy = arm.Strategy
+
+		var ok bool
+		if bs, ok = r.selectors[arm.Strategy]; !ok {
+			return storage.BannerRotation{}, fmt.Errorf("experiment %d: unknown selection strategy %q", exp.ID, arm.Strategy)
+		}
+	}
+
+	selectCtx, span := tracing.Start(ctx, "banner.select", trace.WithAttributes(
+		attribute.Int("slot.id", slotID),
+		attribute.Int("group.id", sGroupID),
+		attribute.String("strategy", strategy),
+		attribute.Int("experiment.id", exp.ID),
+		attribute.String("experiment.arm", arm.Name),
+	))
+	selectStart := time.Now()
+	banner, err := bs.GetBanner(selectCtx, slotID, sGroupID, attrs)
+	metrics.ObserveSelection(strategy, time.Since(selectStart))
+	span.SetAttributes(attribute.Int("banner.id", banner.ID))
+	tracing.End(span, err)
+	if err != nil || banner.ID == 0 {
+		return storage.BannerRotation{Banner: banner}, err
+	}
+
+	show := storage.Statistic{
+		BannerID:      banner.ID,
+		SlotID:        slotID,
+		SosialGroupID: sGroupID,
+	}
+	rotation := storage.BannerRotation{Banner: banner}
+
+	if arm.Name != "" {
+		show.ExperimentID, show.Arm = exp.ID, arm.Name
+		rotation.ExperimentID, rotation.Arm = exp.ID, arm.Name
+
+		if err = a.storage.UpdateArmShowStat(ctx, show); err != nil {
+			return storage.BannerRotation{}, err
+		}
+	}
+
+	rotation.ImpressionID, err = a.storage.CreateImpression(ctx, show)
+	if err != nil {
+		return storage.BannerRotation{}, err
+	}
+	metrics.CountShows(slotID, 1)
+
+	return rotation, nil
 }
 
-func (a App) UpdateShowStat(ctx context.Context, stat storage.Statistic) error {
-	return a.storage.UpdateShowStat(ctx, stat)
+// GetBannerRotations selects banners for all the slots of a page. With unique
+// set no banner is returned for more than one slot. Slots without eligible
+// banners are missing from the result. Slots under experiment are served by
+// the strategy of a picked arm.
+//
+// The banners and the experiments of the slots are read by one query and the
+// shows are written by a second statement. They do not share a transaction, a
+// banner removed from the rotation in between may still be shown once.
+func (a App) GetBannerRotations(ctx context.Context, slotIDs []int, sGroupID int,
+	attrs segment.Attributes, unique bool,
+) (map[int]storage.BannerRotation, error) {
+	if err := validateRotationsRequest(slotIDs, sGroupID); err != nil {
+		return nil, err
+	}
+
+	sGroupID, err := a.resolveGroup(ctx, sGroupID, attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	selectCtx, span := tracing.Start(ctx, "banner.select_batch", trace.WithAttributes(
+		attribute.IntSlice("slot.ids", slotIDs),
+		attribute.Int("group.id", sGroupID),
+		attribute.Bool("unique", unique),
+	))
+	candidates, err := a.storage.GetSlotsBanners(selectCtx, slotIDs, sGroupID)
+	if err != nil {
+		tracing.End(span, err)
+		return nil, err
+	}
+
+	experiments := make(map[int]storage.Experiment)
+	for _, c := range candidates {
+		if c.Experiment.ID != 0 && len(c.Experiment.Arms) > 0 {
+			experiments[c.Stat.SlotID] = c.Experiment
+		}
+	}
+
+	r := a.rotation.Load()
+	arms := make(map[int]storage.ExperimentArm, len(experiments))
+	for slotID, exp := range experiments {
+		arm := experiment.PickArm(exp.Arms)
+		if _, ok := r.selectors[arm.Strategy]; !ok {
+			err := fmt.Errorf("experiment %d: unknown selection strategy %q", exp.ID, arm.Strategy)
+			tracing.End(span, err)
+			return nil, err
+		}
+		arms[slotID] = arm
+	}
+
+	strategies := make([]string, 0)
+	picked := banner.PickBatch(func(slotID int) banner.BannerSelector {
+		bs, strategy := r.forSlot(slotID)
+		if arm, ok := arms[slotID]; ok {
+			bs, strategy = r.selectors[arm.Strategy], arm.Strategy
+		}
+		if !slices.Contains(strategies, strategy) {
+			strategies = append(strategies, strategy)
+		}
+		return timedSelector{BannerSelector: bs, strategy: strategy}
+	}, candidates, slotIDs, attrs, unique)
+	span.SetAttributes(
+		attribute.StringSlice("strategies", strategies),
+		attribute.Int("experiments", len(arms)),
+		attribute.Int("banners.picked", len(picked)),
+	)
+	span.End()
+	if len(picked) == 0 {
+		return map[int]storage.BannerRotation{}, nil
+	}
+
+	// do not count the shows of banners nobody will see
+	if err := apperror.Canceled(ctx); err != nil {
+		return nil, err
+	}
+
+	shows := make([]storage.Statistic, 0, len(picked))
+	for slotID, c := range picked {
+		show := storage.Statistic{
+			BannerID:      c.Banner.ID,
+			SlotID:        slotID,
+			SosialGroupID: sGroupID,
+		}
+		if arm, ok := arms[slotID]; ok {
+			show.ExperimentID, show.Arm = experiments[slotID].ID, arm.Name
+		}
+		shows = append(shows, show)
+	}
+
+	impressions, err := a.storage.RecordShows(ctx, shows)
+	if err != nil {
+		return nil, err
+	}
+
+	rotations := make(map[int]storage.BannerRotation, len(picked))
+	for _, show := range shows {
+		metrics.CountShows(show.SlotID, 1)
+		c := picked[show.SlotID]
+		rotations[show.SlotID] = storage.BannerRotation{
+			// the pricing of the advertiser is not sent to the page
+			Banner:       storage.Banner{ID: c.Banner.ID, Descr: c.Banner.Descr},
+			ExperimentID: show.ExperimentID,
+			Arm:          show.Arm,
+			ImpressionID: impressions[show.SlotID],
+		}
+	}
+
+	return rotations, nil
 }
-func (a App) UpdateClickStat(ctx context.Context, stat storage.Statistic) error {
-	return a.storage.UpdateClickStat(ctx, stat)
+
+// resolveGroup returns sGroupID or, when it is zero, the social group of the
+// request tenant resolved from the request attributes.
+func (a App) resolveGroup(ctx context.Context, sGroupID int, attrs segment.Attributes) (int, error) {
+	if sGroupID != 0 {
+		return sGroupID, nil
+	}
+
+	groupID, ok := a.segments.Resolve(tenant.FromContext(ctx), attrs)
+	if !ok {
+		return 0, apperror.Validation("social group is not resolved from request attributes")
+	}
+
+	return groupID, nil
+}
+
+// UpdateClickStat counts the click unless the fraud filters flag it. A flagged
+// click is stored for audit and the client is not told about it. The click is
+// counted in the experiment arm of its impression, a click without an
+// impression must name an arm of the experiment of the slot.
+func (a App) UpdateClickStat(ctx context.Context, stat storage.Statistic, client storage.ClientInfo) error {
+	if err := a.validateStat(ctx, stat); err != nil {
+		return err
+	}
+
+	click := fraud.Click{Stat: stat, Client: client, At: time.Now()}
+	if stat.ImpressionID != 0 {
+		impression, err := a.storage.GetImpression(ctx, stat.ImpressionID)
+		if err != nil && apperror.KindOf(err) != apperror.KindNotFound {
+			return err
+		}
+		if err == nil {
+			// the click must not be attributed to the show of another banner
+			if impression.BannerID != stat.BannerID || impression.SlotID != stat.SlotID {
+				return apperror.InvalidFields(map[string]string{
+					"ImpressionID": "belongs to another banner or slot",
+				})
+			}
+			click.Impression = &impression
+			stat.ExperimentID, stat.Arm = impression.ExperimentID, impression.Arm
+		}
+	}
+
+	if click.Impression == nil && stat.ExperimentID != 0 {
+		if err := a.validateArm(ctx, stat); err != nil {
+			return err
+		}
+	}
+
+	if reasons := a.clickFilters.Check(click); len(reasons) > 0 {
+		metrics.CountInvalidClick(reasons)
+		return a.storage.SaveInvalidClicks(ctx, []storage.InvalidClick{{Stat: stat, Client: client, Reasons: reasons}})
+	}
+
+	if err := a.storage.UpdateClickStat(ctx, stat); err != nil {
+		return err
+	}
+	metrics.CountClicks(stat.SlotID, 1)
+
+	return nil
+}
+
+// RecordEvents applies shows and clicks collected outside of the service in
+// bulk. The batch is rejected as a whole when any of the events is invalid.
+// The clicks go through the fraud filters like the single ones, the flagged
+// clicks are stored for audit and not counted.
+func (a App) RecordEvents(ctx context.Context, events []storage.Event) error {
+	if err := validateEvents(events); err != nil {
+		return err
+	}
+
+	valid, err := a.filterClickEvents(ctx, events)
+	if err != nil {
+		return err
+	}
+	if len(valid) == 0 {
+		return nil
+	}
+
+	if err := a.storage.RecordEvents(ctx, valid); err != nil {
+		return err
+	}
+
+	for _, event := range valid {
+		if event.Type == storage.EventClick {
+			metrics.CountClicks(event.SlotID, 1)
+		} else {
+			metrics.CountShows(event.SlotID, 1)
+		}
+	}
+
+	return nil
+}
+
+// filterClickEvents runs the clicks through the fraud filters and stores the
+// flagged ones. It returns the events to count, the clicks of an impression
+// count in its experiment arm. The impressions of the clicks
+// are read and the flagged clicks are stored in one query each. The clicks are
+// checked at the time they were made, the client filters skip the clicks
+// reported without the client.
+func (a App) filterClickEvents(ctx context.Context, events []storage.Event) ([]storage.Event, error) {
+	impressionIDs := make([]int64, 0)
+	for _, event := range events {
+		if event.Type == storage.EventClick && event.ImpressionID != 0 {
+			impressionIDs = append(impressionIDs, event.ImpressionID)
+		}
+	}
+
+	impressions := map[int64]storage.Impression{}
+	if len(impressionIDs) > 0 {
+		var err error
+		if impressions, err = a.storage.GetImpressions(ctx, impressionIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	clicks := make(map[int]fraud.Click)
+	fields := make(map[string]string)
+	for i, event := range events {
+		if event.Type != storage.EventClick {
+			continue
+		}
+
+		click := fraud.Click{
+			Stat: storage.Statistic{
+				BannerID:      event.BannerID,
+				SlotID:        event.SlotID,
+				SosialGroupID: event.SosialGroupID,
+				ExperimentID:  event.ExperimentID,
+				Arm:           event.Arm,
+				ImpressionID:  event.ImpressionID,
+			},
+			Client:  storage.ClientInfo{IP: event.IP, UserAgent: event.UserAgent},
+			At:      event.At,
+			Relayed: true,
+		}
+		if click.At.IsZero() {
+			click.At = now
+		}
+		if impression, ok := impressions[event.ImpressionID]; ok {
+			// the click must not be attributed to the show of another banner
+			if impression.BannerID != event.BannerID || impression.SlotID != event.SlotID {
+				fields[fmt.Sprintf("Events[%d].ImpressionID", i)] = "belongs to another banner or slot"
+				continue
+			}
+			click.Impression = &impression
+			click.Stat.ExperimentID, click.Stat.Arm = impression.ExperimentID, impression.Arm
+		}
+		clicks[i] = click
+	}
+	if len(fields) > 0 {
+		return nil, apperror.InvalidFields(fields)
+	}
+
+	valid := make([]storage.Event, 0, len(events))
+	invalid := make([]storage.InvalidClick, 0)
+	for i, event := range events {
+		click, ok := clicks[i]
+		if !ok {
+			valid = append(valid, event)
+			continue
+		}
+
+		reasons := a.clickFilters.Check(click)
+		if len(reasons) == 0 {
+			event.ExperimentID, event.Arm = click.Stat.ExperimentID, click.Stat.Arm
+			valid = append(valid, event)
+			continue
+		}
+
+		metrics.CountInvalidClick(reasons)
+		invalid = append(invalid, storage.InvalidClick{Stat: click.Stat, Client: click.Client, Reasons: reasons})
+	}
+
+	if len(invalid) > 0 {
+		if err := a.storage.SaveInvalidClicks(ctx, invalid); err != nil {
+			return nil, err
+		}
+	}
+
+	return valid, nil
+}
+
+func (a App) CreateExperiment(ctx context.Context, exp storage.Experiment) (int, error) {
+	if err := experiment.Validate(exp, banner.Strategies); err != nil {
+		return 0, err
+	}
+
+	return a.storage.CreateExperiment(ctx, exp)
+}
+
+func (a App) GetExperimentReport(ctx context.Context, experimentID int) ([]experiment.ArmReport, error) {
+	exp, err := a.storage.GetExperiment(ctx, experimentID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := a.storage.GetExperimentStat(ctx, experimentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return experiment.Report(exp, stats), nil
+}
+
+// TrackConversion attributes a post-click conversion to its impression. The
+// conversion is counted only once and only within the attribution window
+// after the click.
+func (a App) TrackConversion(ctx context.Context, conv storage.Conversion) error {
+	if err := validateConversion(conv); err != nil {
+		return err
+	}
+
+	impression, err := a.storage.GetImpression(ctx, conv.ImpressionID)
+	if err != nil {
+		return err
+	}
+
+	if impression.ClickedAt == nil {
+		return apperror.Validation("impression %d was not clicked", conv.ImpressionID)
+	}
+
+	if time.Since(*impression.ClickedAt) > a.rotation.Load().attributionWindow {
+		return apperror.Validation("impression %d is outside the attribution window", conv.ImpressionID)
+	}
+
+	added, err := a.storage.AddConversion(ctx, conv)
+	if err != nil {
+		return err
+	}
+
+	if !added {
+		return apperror.Conflict("impression %d is already converted", conv.ImpressionID)
+	}
+
+	return nil
+}
+
+// GetRevenueReport returns the revenue of every slot, or of the single slot
+// when slotID is not zero.
+func (a App) GetRevenueReport(ctx context.Context, slotID int) ([]banner.SlotRevenue, error) {
+	stats, err := a.storage.GetRevenueStat(ctx, slotID)
+	if err != nil {
+		return nil, err
+	}
+
+	return banner.RevenueReport(stats), nil
+}
+
+// ClaimIdempotencyKey reserves the key for the request with the fingerprint.
+// When the key is already used within the window it reports false with the
+// stored response. A claim without a response is taken over once the lease
+// is over, as the request holding it is gone.
+func (a App) ClaimIdempotencyKey(ctx context.Context, key, fingerprint string,
+	window, lease time.Duration,
+) (storage.IdempotentResponse, bool, error) {
+	if err := validateIdempotencyKey(key); err != nil {
+		return storage.IdempotentResponse{}, false, err
+	}
+
+	return a.storage.ClaimIdempotencyKey(ctx, key, fingerprint, window, lease)
+}
+
+func (a App) SaveIdempotentResponse(ctx context.Context, key string, resp storage.IdempotentResponse) error {
+	return a.storage.SaveIdempotentResponse(ctx, key, resp)
+}
+
+// ReleaseIdempotencyKey forgets the key, so a failed request can be retried
+// with it.
+func (a App) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	return a.storage.DeleteIdempotencyKey(ctx, key)
+}
+
+// Authenticate finds the API key and checks that it grants the scope.
+func (a App) Authenticate(ctx context.Context, key string, scope auth.Scope) (storage.APIKey, error) {
+	if key == "" {
+		return storage.APIKey{}, apperror.Unauthenticated("API key is required")
+	}
+
+	apiKey, err := a.storage.GetAPIKey(ctx, auth.HashKey(key))
+	if apperror.KindOf(err) == apperror.KindNotFound {
+		return storage.APIKey{}, apperror.Unauthenticated("API key is invalid or revoked")
+	}
+	if err != nil {
+		return storage.APIKey{}, err
+	}
+
+	if !auth.Allows(apiKey.Scopes, scope) {
+		return storage.APIKey{}, apperror.PermissionDenied("API key has no %s scope", scope)
+	}
+
+	return apiKey, nil
+}
+
+// CreateAPIKey stores a new API key of the tenant and returns it with the key
+// itself, which cannot be recovered later.
+func (a App) CreateAPIKey(ctx context.Context, name, tenantID string, scopes []string) (storage.APIKey, string, error) {
+	if err := validateAPIKey(name, tenantID, scopes); err != nil {
+		return storage.APIKey{}, "", err
+	}
+
+	key, err := auth.GenerateKey()
+	if err != nil {
+		return storage.APIKey{}, "", err
+	}
+
+	apiKey := storage.APIKey{Name: name, Tenant: tenantID, Scopes: scopes}
+	apiKey.ID, err = a.storage.CreateAPIKey(ctx, apiKey, auth.HashKey(key))
+	if err != nil {
+		return storage.APIKey{}, "", err
+	}
+
+	return apiKey, key, nil
+}
+
+func (a App) ListAPIKeys(ctx context.Context) ([]storage.APIKey, error) {
+	return a.storage.ListAPIKeys(ctx)
+}
+
+func (a App) RevokeAPIKey(ctx context.Context, keyID int) error {
+	return a.storage.RevokeAPIKey(ctx, keyID)
 }
@@ -0,0 +1,510 @@
+package app
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/auth"
+	"github.com/otus-murashko/banners-rotation/internal/banner"
+	"github.com/otus-murashko/banners-rotation/internal/config"
+	"github.com/otus-murashko/banners-rotation/internal/fraud"
+	"github.com/otus-murashko/banners-rotation/internal/metrics"
+	"github.com/otus-murashko/banners-rotation/internal/segment"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+	"github.com/otus-murashko/banners-rotation/internal/tenant"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// fakeStorage keeps in memory what the tests look at. The methods a test does
+// not set up panic through the embedded nil storage.
+type fakeStorage struct {
+	storage.Storage
+	experiments   map[int]storage.Experiment
+	candidates    []tenantBanner
+	impressions   map[int64]storage.Impression
+	shows         []storage.Statistic
+	clicks        []storage.Statistic
+	events        []storage.Event
+	invalidClicks [][]storage.InvalidClick
+	apiKeys       map[string]storage.APIKey
+}
+
+// tenantBanner is a candidate of the fake storage, only its tenant reads it.
+type tenantBanner struct {
+	storage.SlotBanner
+	Tenant string
+}
+
+func (s *fakeStorage) BannerExists(context.Context, int) (bool, error) { return true, nil }
+func (s *fakeStorage) SlotExists(context.Context, int) (bool, error)   { return true, nil }
+func (s *fakeStorage) GroupExists(context.Context, int) (bool, error)  { return true, nil }
+
+func (s *fakeStorage) GetExperiment(_ context.Context, experimentID int) (storage.Experiment, error) {
+	exp, ok := s.experiments[experimentID]
+	if !ok {
+		return storage.Experiment{}, apperror.NotFound("experiment %d not found", experimentID)
+	}
+	return exp, nil
+}
+
+// GetAPIKey finds the key by its hash, the revoked keys are not found like in
+// the database.
+func (s *fakeStorage) GetAPIKey(_ context.Context, keyHash string) (storage.APIKey, error) {
+	key, ok := s.apiKeys[keyHash]
+	if !ok || key.RevokedAt != nil {
+		return storage.APIKey{}, apperror.NotFound("API key not found")
+	}
+	return key, nil
+}
+
+func (s *fakeStorage) GetImpression(_ context.Context, impressionID int64) (storage.Impression, error) {
+	impression, ok := s.impressions[impressionID]
+	if !ok {
+		return storage.Impression{}, apperror.NotFound("impression %d not found", impressionID)
+	}
+	return impression, nil
+}
+
+func (s *fakeStorage) UpdateClickStat(_ context.Context, stat storage.Statistic) error {
+	s.clicks = append(s.clicks, stat)
+	return nil
+}
+
+func (s *fakeStorage) GetSlotsBanners(ctx context.Context, slotIDs []int, groupID int) ([]storage.SlotBanner, error) {
+	candidates := make([]storage.SlotBanner, 0)
+	for _, c := range s.candidates {
+		if slices.Contains(slotIDs, c.Stat.SlotID) && c.Stat.SosialGroupID == groupID &&
+			c.Tenant == tenant.FromContext(ctx) {
+			candidates = append(candidates, c.SlotBanner)
+		}
+	}
+	return candidates, nil
+}
+
+func (s *fakeStorage) RecordShows(_ context.Context, shows []storage.Statistic) (map[int]int64, error) {
+	impressions := make(map[int]int64, len(shows))
+	for _, show := range shows {
+		s.shows = append(s.shows, show)
+		impressions[show.SlotID] = int64(len(s.shows))
+	}
+	return impressions, nil
+}
+
+func (s *fakeStorage) GetImpressions(_ context.Context, ids []int64) (map[int64]storage.Impression, error) {
+	found := make(map[int64]storage.Impression)
+	for _, id := range ids {
+		if impression, ok := s.impressions[id]; ok {
+			found[id] = impression
+		}
+	}
+	return found, nil
+}
+
+func (s *fakeStorage) RecordEvents(_ context.Context, events []storage.Event) error {
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func (s *fakeStorage) SaveInvalidClicks(_ context.Context, clicks []storage.InvalidClick) error {
+	s.invalidClicks = append(s.invalidClicks, clicks)
+	return nil
+}
+
+func newTestApp(t *testing.T, s storage.Storage, segments config.Segmentation, filters fraud.Chain) *App {
+	t.Helper()
+
+	a, err := New(s, segment.NewResolver(segments), filters, config.Rotation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestRecordEventsFiltersClicks(t *testing.T) {
+	shownAt := time.Now().Add(-time.Hour)
+	s := &fakeStorage{impressions: map[int64]storage.Impression{
+		7: {ID: 7, BannerID: 1, SlotID: 2, ShownAt: shownAt},
+	}}
+	filters := fraud.NewChain(config.Fraud{
+		Burst:         config.ClickBurst{Clicks: 1, Window: time.Minute},
+		MinClickDelay: time.Second,
+		BotUserAgents: []string{"bot"},
+	})
+	a := newTestApp(t, s, config.Segmentation{}, filters)
+
+	event := func(ip, userAgent string, at time.Time) storage.Event {
+		return storage.Event{
+			Type: storage.EventClick, BannerID: 1, SlotID: 2, SosialGroupID: 3,
+			ImpressionID: 7, IP: ip, UserAgent: userAgent, At: at,
+		}
+	}
+	show := storage.Event{Type: storage.EventShow, BannerID: 1, SlotID: 2, SosialGroupID: 3}
+
+	events := []storage.Event{
+		show,
+		// the collector does not know the client
+		event("", "", shownAt.Add(time.Minute)),
+		event("", "", shownAt.Add(time.Minute)),
+		// clicks of one IP made a minute apart, reported at once
+		event("10.0.0.1", "Mozilla", shownAt.Add(time.Minute)),
+		event("10.0.0.1", "Mozilla", shownAt.Add(2*time.Minute)),
+		// flagged
+		event("10.0.0.2", "bot/1.0", shownAt.Add(time.Minute)),
+		event("10.0.0.3", "Mozilla", shownAt),
+	}
+
+	if err := a.RecordEvents(context.Background(), events); err != nil {
+		t.Fatalf("RecordEvents() error = %v", err)
+	}
+
+	if want := events[:5]; !slices.Equal(s.events, want) {
+		t.Errorf("recorded events = %+v, want %+v", s.events, want)
+	}
+
+	if len(s.invalidClicks) != 1 {
+		t.Fatalf("invalid clicks saved in %d calls, want 1", len(s.invalidClicks))
+	}
+	gotReasons := make([][]string, 0)
+	for _, click := range s.invalidClicks[0] {
+		gotReasons = append(gotReasons, click.Reasons)
+	}
+	wantReasons := [][]string{{fraud.ReasonBotUserAgent}, {fraud.ReasonFastClick}}
+	if !slices.EqualFunc(gotReasons, wantReasons, slices.Equal) {
+		t.Errorf("invalid click reasons = %v, want %v", gotReasons, wantReasons)
+	}
+}
+
+func TestUpdateClickStatArm(t *testing.T) {
+	arms := []storage.ExperimentArm{{Name: "a", Strategy: "ucb1"}, {Name: "b", Strategy: "thompson"}}
+	experiments := map[int]storage.Experiment{
+		5: {ID: 5, SlotID: 2, Arms: arms},
+		6: {ID: 6, SlotID: 3, Arms: arms},
+	}
+	impressions := map[int64]storage.Impression{
+		7: {ID: 7, BannerID: 1, SlotID: 2, ExperimentID: 5, Arm: "a"},
+		8: {ID: 8, BannerID: 1, SlotID: 2},
+	}
+	click := func(experimentID int, arm string, impressionID int64) storage.Statistic {
+		return storage.Statistic{
+			BannerID: 1, SlotID: 2, SosialGroupID: 3,
+			ExperimentID: experimentID, Arm: arm, ImpressionID: impressionID,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		click     storage.Statistic
+		wantClick storage.Statistic
+		want      map[string]string
+	}{
+		{
+			name:      "arm of the impression",
+			click:     click(6, "b", 7),
+			wantClick: click(5, "a", 7),
+		},
+		{
+			name:      "impression out of experiment",
+			click:     click(5, "b", 8),
+			wantClick: click(0, "", 8),
+		},
+		{
+			name:      "arm without impression",
+			click:     click(5, "b", 0),
+			wantClick: click(5, "b", 0),
+		},
+		{
+			name:      "unknown impression",
+			click:     click(5, "b", 9),
+			wantClick: click(5, "b", 9),
+		},
+		{
+			name:  "experiment of another slot",
+			click: click(6, "a", 0),
+			want:  map[string]string{"ExperimentID": "is not an experiment of the slot"},
+		},
+		{
+			name:  "unknown experiment",
+			click: click(4, "a", 0),
+			want:  map[string]string{"ExperimentID": "is not an experiment of the slot"},
+		},
+		{
+			name:  "unknown arm",
+			click: click(5, "c", 0),
+			want:  map[string]string{"Arm": "is not an arm of the experiment"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &fakeStorage{experiments: experiments, impressions: impressions}
+			a := newTestApp(t, s, config.Segmentation{}, nil)
+
+			err := a.UpdateClickStat(context.Background(), tt.click, storage.ClientInfo{})
+			checkFields(t, err, tt.want)
+
+			var want []storage.Statistic
+			if tt.want == nil {
+				want = []storage.Statistic{tt.wantClick}
+			}
+			if !slices.Equal(s.clicks, want) {
+				t.Errorf("counted clicks = %+v, want %+v", s.clicks, want)
+			}
+		})
+	}
+}
+
+// selections returns the number of selections recorded for the strategy.
+func selections(t *testing.T, strategy string) uint64 {
+	t.Helper()
+
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "banners_rotation_selection_duration_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "strategy" && label.GetValue() == strategy {
+					return m.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestGetBannerRotationsStrategies(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	exp := storage.Experiment{ID: 5, SlotID: 2, Active: true, Arms: []storage.ExperimentArm{
+		{Name: "t", Strategy: banner.StrategyThompson, Weight: 1},
+	}}
+	candidate := func(bannerID, slotID int, exp storage.Experiment) tenantBanner {
+		return tenantBanner{Tenant: tenant.Default, SlotBanner: storage.SlotBanner{
+			Banner:     storage.Banner{ID: bannerID, Descr: "banner"},
+			Stat:       storage.Statistic{BannerID: bannerID, SlotID: slotID, SosialGroupID: 3},
+			Experiment: exp,
+		}}
+	}
+	s := &fakeStorage{candidates: []tenantBanner{
+		candidate(10, 1, storage.Experiment{}),
+		candidate(20, 2, exp),
+		candidate(30, 3, storage.Experiment{}),
+	}}
+	a, err := New(s, segment.NewResolver(config.Segmentation{}), nil, config.Rotation{
+		Slots: map[int]config.SlotRotation{3: {Strategy: banner.StrategyRevenue}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	strategies := []string{banner.StrategyUCB1, banner.StrategyThompson, banner.StrategyRevenue}
+	before := make(map[string]uint64, len(strategies))
+	for _, strategy := range strategies {
+		before[strategy] = selections(t, strategy)
+	}
+
+	rotations, err := a.GetBannerRotations(context.Background(), []int{1, 2, 3}, 3, segment.Attributes{}, false)
+	if err != nil {
+		t.Fatalf("GetBannerRotations() error = %v", err)
+	}
+
+	want := map[int]storage.BannerRotation{
+		1: {Banner: storage.Banner{ID: 10, Descr: "banner"}, ImpressionID: rotations[1].ImpressionID},
+		2: {
+			Banner:       storage.Banner{ID: 20, Descr: "banner"},
+			ExperimentID: 5, Arm: "t",
+			ImpressionID: rotations[2].ImpressionID,
+		},
+		3: {Banner: storage.Banner{ID: 30, Descr: "banner"}, ImpressionID: rotations[3].ImpressionID},
+	}
+	if !maps.Equal(rotations, want) {
+		t.Errorf("GetBannerRotations() = %+v, want %+v", rotations, want)
+	}
+
+	for _, show := range s.shows {
+		wantExperiment := 0
+		if show.SlotID == 2 {
+			wantExperiment = 5
+		}
+		if show.ExperimentID != wantExperiment {
+			t.Errorf("show of slot %d recorded in experiment %d, want %d", show.SlotID, show.ExperimentID, wantExperiment)
+		}
+	}
+
+	for _, strategy := range strategies {
+		if got := selections(t, strategy) - before[strategy]; got != 1 {
+			t.Errorf("%s selections = %d, want 1", strategy, got)
+		}
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("ended spans = %d, want 1", len(spans))
+	}
+	var got []string
+	for _, attr := range spans[0].Attributes() {
+		if attr.Key == "strategies" {
+			got = attr.Value.AsStringSlice()
+		}
+	}
+	if !slices.Equal(got, strategies) {
+		t.Errorf("span strategies = %v, want %v", got, strategies)
+	}
+}
+
+func TestGetBannerRotationsTenantGroup(t *testing.T) {
+	segments := config.Segmentation{
+		Rules: []config.SegmentRule{{GroupID: 1, Countries: []string{"RU"}}},
+		Tenants: map[string]config.TenantSegmentation{
+			"acme": {Rules: []config.SegmentRule{{GroupID: 7, Countries: []string{"RU"}}}},
+		},
+	}
+	candidate := func(tenantID string, bannerID, groupID int) tenantBanner {
+		return tenantBanner{Tenant: tenantID, SlotBanner: storage.SlotBanner{
+			Banner: storage.Banner{ID: bannerID, Descr: "banner"},
+			Stat:   storage.Statistic{BannerID: bannerID, SlotID: 2, SosialGroupID: groupID},
+		}}
+	}
+
+	tests := []struct {
+		name       string
+		tenantID   string
+		wantBanner int
+		wantGroup  int
+	}{
+		{name: "default tenant", tenantID: tenant.Default, wantBanner: 10, wantGroup: 1},
+		{name: "another tenant", tenantID: "acme", wantBanner: 20, wantGroup: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &fakeStorage{candidates: []tenantBanner{
+				candidate(tenant.Default, 10, 1),
+				candidate("acme", 20, 7),
+			}}
+			a := newTestApp(t, s, segments, nil)
+
+			ctx := tenant.WithTenant(context.Background(), tt.tenantID)
+			rotations, err := a.GetBannerRotations(ctx, []int{2}, 0, segment.Attributes{Country: "RU"}, false)
+			if err != nil {
+				t.Fatalf("GetBannerRotations() error = %v", err)
+			}
+
+			if got := rotations[2].Banner.ID; got != tt.wantBanner {
+				t.Errorf("banner = %d, want %d", got, tt.wantBanner)
+			}
+			if len(s.shows) != 1 || s.shows[0].SosialGroupID != tt.wantGroup {
+				t.Errorf("shows = %+v, want one of group %d", s.shows, tt.wantGroup)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	revokedAt := time.Now()
+	s := &fakeStorage{apiKeys: map[string]storage.APIKey{
+		auth.HashKey("br_read"):    {ID: 1, Tenant: "acme", Scopes: []string{"read"}},
+		auth.HashKey("br_admin"):   {ID: 2, Tenant: "acme", Scopes: []string{"admin"}},
+		auth.HashKey("br_revoked"): {ID: 3, Tenant: "acme", Scopes: []string{"admin"}, RevokedAt: &revokedAt},
+	}}
+	a := newTestApp(t, s, config.Segmentation{}, nil)
+
+	tests := []struct {
+		name     string
+		key      string
+		scope    auth.Scope
+		wantID   int
+		wantKind apperror.Kind
+	}{
+		{name: "missing key", scope: auth.ScopeRead, wantKind: apperror.KindUnauthenticated},
+		{name: "unknown key", key: "br_unknown", scope: auth.ScopeRead, wantKind: apperror.KindUnauthenticated},
+		{name: "revoked key", key: "br_revoked", scope: auth.ScopeRead, wantKind: apperror.KindUnauthenticated},
+		{name: "scope denied", key: "br_read", scope: auth.ScopeTrack, wantKind: apperror.KindPermissionDenied},
+		{name: "scope of the key", key: "br_read", scope: auth.ScopeRead, wantID: 1},
+		{name: "admin key", key: "br_admin", scope: auth.ScopeTrack, wantID: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := a.Authenticate(context.Background(), tt.key, tt.scope)
+			if tt.wantKind != "" {
+				if apperror.KindOf(err) != tt.wantKind {
+					t.Errorf("Authenticate() error = %v, want %s", err, tt.wantKind)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if key.ID != tt.wantID {
+				t.Errorf("Authenticate() key = %d, want %d", key.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestApplyRotation(t *testing.T) {
+	a := newTestApp(t, &fakeStorage{}, config.Segmentation{}, nil)
+
+	tests := []struct {
+		name         string
+		conf         config.Rotation
+		wantErr      bool
+		wantStrategy string
+		wantSlot     string
+	}{
+		{
+			name:         "strategy and slot",
+			conf:         config.Rotation{Strategy: "thompson", Slots: map[int]config.SlotRotation{2: {Strategy: "revenue"}}},
+			wantStrategy: "thompson",
+			wantSlot:     "revenue",
+		},
+		{
+			name:         "unknown strategy keeps the running rotation",
+			conf:         config.Rotation{Strategy: "greedy"},
+			wantErr:      true,
+			wantStrategy: "thompson",
+			wantSlot:     "revenue",
+		},
+		{
+			name:         "unknown objective keeps the running rotation",
+			conf:         config.Rotation{Objective: "clicks"},
+			wantErr:      true,
+			wantStrategy: "thompson",
+			wantSlot:     "revenue",
+		},
+		{
+			name:         "defaults",
+			wantStrategy: "ucb1",
+			wantSlot:     "ucb1",
+		},
+	}
+
+	for _, tt := range tests {
+		err := a.ApplyRotation(tt.conf)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ApplyRotation() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+
+		r := a.rotation.Load()
+		_, slotStrategy := r.forSlot(2)
+		if r.strategy != tt.wantStrategy || slotStrategy != tt.wantSlot {
+			t.Errorf("%s: strategy = %q, slot strategy = %q, want %q, %q",
+				tt.name, r.strategy, slotStrategy, tt.wantStrategy, tt.wantSlot)
+		}
+	}
+}
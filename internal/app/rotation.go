@@ -0,0 +1,121 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/banner"
+	"github.com/otus-murashko/banners-rotation/internal/config"
+	"github.com/otus-murashko/banners-rotation/internal/metrics"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+// rotation is the selection setup of the config. It is replaced as a whole
+// on reload, so a request sees either the old or the new one.
+type rotation struct {
+	strategy string
+	bs       BannerSelector
+	// slots override the strategy and the objective of some slots
+	slots map[int]slotSelector
+	// selectors serve the experiment arms with the default objective
+	selectors         map[string]BannerSelector
+	attributionWindow time.Duration
+}
+
+type slotSelector struct {
+	strategy string
+	bs       BannerSelector
+}
+
+func newRotation(storage storage.Storage, conf config.Rotation) (*rotation, error) {
+	objective, err := banner.ParseObjective(conf.Objective)
+	if err != nil {
+		return nil, err
+	}
+
+	selectors := make(map[string]BannerSelector, len(banner.Strategies))
+	for _, strategy := range banner.Strategies {
+		bs, err := banner.NewSelector(strategy, objective, storage)
+		if err != nil {
+			return nil, err
+		}
+		selectors[strategy] = bs
+	}
+
+	strategy := conf.Strategy
+	if strategy == "" {
+		strategy = banner.StrategyUCB1
+	}
+	bs, ok := selectors[strategy]
+	if !ok {
+		return nil, fmt.Errorf("unknown selection strategy %q", strategy)
+	}
+
+	slots := make(map[int]slotSelector, len(conf.Slots))
+	for slotID, slotConf := range conf.Slots {
+		slotStrategy, slotObjective := slotConf.Strategy, slotConf.Objective
+		if slotStrategy == "" {
+			slotStrategy = strategy
+		}
+		if slotObjective == "" {
+			slotObjective = string(objective)
+		}
+
+		parsed, err := banner.ParseObjective(slotObjective)
+		if err != nil {
+			return nil, fmt.Errorf("slot %d: %w", slotID, err)
+		}
+		slotBS, err := banner.NewSelector(slotStrategy, parsed, storage)
+		if err != nil {
+			return nil, fmt.Errorf("slot %d: %w", slotID, err)
+		}
+		slots[slotID] = slotSelector{strategy: slotStrategy, bs: slotBS}
+	}
+
+	attributionWindow := conf.AttributionWindow
+	if attributionWindow <= 0 {
+		attributionWindow = defaultAttributionWindow
+	}
+
+	return &rotation{
+		strategy:          strategy,
+		bs:                bs,
+		slots:             slots,
+		selectors:         selectors,
+		attributionWindow: attributionWindow,
+	}, nil
+}
+
+// forSlot returns the selector of the slot and the name of its strategy.
+func (r *rotation) forSlot(slotID int) (BannerSelector, string) {
+	if s, ok := r.slots[slotID]; ok {
+		return s.bs, s.strategy
+	}
+	return r.bs, r.strategy
+}
+
+// timedSelector records the time of every pick under the strategy that
+// served it, a batch may be served by several strategies.
+type timedSelector struct {
+	BannerSelector
+	strategy string
+}
+
+func (s timedSelector) Pick(candidates []storage.SlotBanner) (storage.SlotBanner, bool) {
+	start := time.Now()
+	picked, ok := s.BannerSelector.Pick(candidates)
+	metrics.ObserveSelection(s.strategy, time.Since(start))
+	return picked, ok
+}
+
+// ApplyRotation replaces the selection setup of the running application. The
+// current one is kept when conf is invalid.
+func (a App) ApplyRotation(conf config.Rotation) error {
+	r, err := newRotation(a.storage, conf)
+	if err != nil {
+		return err
+	}
+
+	a.rotation.Store(r)
+	return nil
+}
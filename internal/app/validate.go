@@ -0,0 +1,213 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/auth"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+	"github.com/otus-murashko/banners-rotation/internal/tenant"
+	"github.com/otus-murashko/banners-rotation/internal/validation"
+)
+
+type existsFunc func(ctx context.Context, id int) (bool, error)
+
+func validateBanner(b storage.Banner) error {
+	v := validation.New()
+
+	v.Check(validation.NotBlank(b.Descr), "Descr", "must not be empty")
+	v.Check(b.Pricing == "" || b.Pricing == storage.PricingCPM || b.Pricing == storage.PricingCPC ||
+		b.Pricing == storage.PricingCPA, "Pricing", "must be one of cpm, cpc, cpa")
+	v.Check(b.Bid >= 0, "Bid", "must not be negative")
+
+	return v.Err()
+}
+
+func validateDescr(descr string) error {
+	v := validation.New()
+
+	v.Check(validation.NotBlank(descr), "Descr", "must not be empty")
+
+	return v.Err()
+}
+
+func validateRotationRequest(slotID, groupID int) error {
+	v := validation.New()
+
+	v.Check(slotID > 0, "slot_id", "must be positive")
+	v.Check(groupID >= 0, "group_id", "must not be negative")
+
+	return v.Err()
+}
+
+func validateConversion(conv storage.Conversion) error {
+	v := validation.New()
+
+	v.Check(conv.ImpressionID > 0, "ImpressionID", "must be positive")
+	v.Check(conv.Value >= 0, "Value", "must not be negative")
+
+	return v.Err()
+}
+
+// maxBatchSlots limits the number of slots selected in one batch.
+const maxBatchSlots = 50
+
+func validateRotationsRequest(slotIDs []int, groupID int) error {
+	v := validation.New()
+
+	v.Check(len(slotIDs) > 0, "SlotIDs", "must not be empty")
+	v.Check(len(slotIDs) <= maxBatchSlots, "SlotIDs", "must not contain more than %d slots", maxBatchSlots)
+
+	seen := make(map[int]struct{}, len(slotIDs))
+	for _, slotID := range slotIDs {
+		_, duplicate := seen[slotID]
+		seen[slotID] = struct{}{}
+
+		v.Check(slotID > 0, "SlotIDs", "must be positive")
+		v.Check(!duplicate, "SlotIDs", "must not contain duplicates")
+	}
+	v.Check(groupID >= 0, "GroupID", "must not be negative")
+
+	return v.Err()
+}
+
+// MaxBatchEvents limits the number of events recorded in one batch.
+const MaxBatchEvents = 10000
+
+// validateEvents checks the events without looking up the referenced entities,
+// the errors are reported by the event index.
+func validateEvents(events []storage.Event) error {
+	v := validation.New()
+
+	v.Check(len(events) > 0, "Events", "must not be empty")
+	v.Check(len(events) <= MaxBatchEvents, "Events", "must not contain more than %d events", MaxBatchEvents)
+	if !v.FieldValid("Events") {
+		return v.Err()
+	}
+
+	for i, e := range events {
+		field := func(name string) string {
+			return fmt.Sprintf("Events[%d].%s", i, name)
+		}
+
+		v.Check(e.Type == storage.EventShow || e.Type == storage.EventClick, field("Type"), "must be one of show, click")
+		v.Check(e.BannerID > 0, field("BannerID"), "must be positive")
+		v.Check(e.SlotID > 0, field("SlotID"), "must be positive")
+		v.Check(e.SosialGroupID > 0, field("SosialGroupID"), "must be positive")
+		v.Check(e.ExperimentID >= 0, field("ExperimentID"), "must not be negative")
+		v.Check(e.ExperimentID == 0 || e.Arm != "", field("Arm"), "is required with ExperimentID")
+		v.Check(e.ImpressionID >= 0, field("ImpressionID"), "must not be negative")
+	}
+
+	return v.Err()
+}
+
+// maxIdempotencyKeyLen limits the length of an Idempotency-Key.
+const maxIdempotencyKeyLen = 255
+
+func validateIdempotencyKey(key string) error {
+	v := validation.New()
+
+	v.Check(validation.NotBlank(key), "Idempotency-Key", "must not be empty")
+	v.Check(len(key) <= maxIdempotencyKeyLen, "Idempotency-Key", "must not be longer than %d characters", maxIdempotencyKeyLen)
+
+	return v.Err()
+}
+
+func validateAPIKey(name, tenantID string, scopes []string) error {
+	v := validation.New()
+
+	v.Check(validation.NotBlank(name), "Name", "must not be empty")
+	v.Check(tenant.Valid(tenantID), "Tenant", "must be lowercase letters, digits, _ or - up to 63 characters")
+	v.Check(len(scopes) > 0, "Scopes", "must not be empty")
+	for _, scope := range scopes {
+		v.Check(auth.ValidScope(scope), "Scopes", "must be one of read, track, admin")
+	}
+
+	return v.Err()
+}
+
+func (a App) validateRotation(ctx context.Context, rotation storage.Rotation) error {
+	v := validation.New()
+
+	v.Check(rotation.BannerID > 0, "BannerID", "must be positive")
+	v.Check(rotation.SlotID > 0, "SlotID", "must be positive")
+
+	t := rotation.Targeting
+	v.Check(validation.NoBlankItems(t.Countries), "Targeting.Countries", "must not contain empty values")
+	v.Check(validation.NoBlankItems(t.Regions), "Targeting.Regions", "must not contain empty values")
+	v.Check(validation.NoBlankItems(t.Devices), "Targeting.Devices", "must not contain empty values")
+	v.Check(validation.NoBlankItems(t.OS), "Targeting.OS", "must not contain empty values")
+	v.Check(validation.NoBlankItems(t.Languages), "Targeting.Languages", "must not contain empty values")
+
+	if err := checkExists(ctx, v, "BannerID", rotation.BannerID, a.storage.BannerExists); err != nil {
+		return err
+	}
+	if err := checkExists(ctx, v, "SlotID", rotation.SlotID, a.storage.SlotExists); err != nil {
+		return err
+	}
+
+	return v.Err()
+}
+
+func (a App) validateStat(ctx context.Context, stat storage.Statistic) error {
+	v := validation.New()
+
+	v.Check(stat.BannerID > 0, "BannerID", "must be positive")
+	v.Check(stat.SlotID > 0, "SlotID", "must be positive")
+	v.Check(stat.SosialGroupID > 0, "SosialGroupID", "must be positive")
+	v.Check(stat.ExperimentID >= 0, "ExperimentID", "must not be negative")
+	v.Check(stat.ExperimentID == 0 || stat.Arm != "", "Arm", "is required with ExperimentID")
+	v.Check(stat.ImpressionID >= 0, "ImpressionID", "must not be negative")
+
+	if err := checkExists(ctx, v, "BannerID", stat.BannerID, a.storage.BannerExists); err != nil {
+		return err
+	}
+	if err := checkExists(ctx, v, "SlotID", stat.SlotID, a.storage.SlotExists); err != nil {
+		return err
+	}
+	if err := checkExists(ctx, v, "SosialGroupID", stat.SosialGroupID, a.storage.GroupExists); err != nil {
+		return err
+	}
+
+	return v.Err()
+}
+
+// validateArm checks that the click names an arm of an experiment of its
+// slot. The experiment may be already stopped, the late clicks of its shows
+// are still counted.
+func (a App) validateArm(ctx context.Context, stat storage.Statistic) error {
+	v := validation.New()
+
+	exp, err := a.storage.GetExperiment(ctx, stat.ExperimentID)
+	if err != nil && apperror.KindOf(err) != apperror.KindNotFound {
+		return err
+	}
+	v.Check(err == nil && exp.SlotID == stat.SlotID, "ExperimentID", "is not an experiment of the slot")
+	if v.FieldValid("ExperimentID") {
+		v.Check(slices.ContainsFunc(exp.Arms, func(arm storage.ExperimentArm) bool {
+			return arm.Name == stat.Arm
+		}), "Arm", "is not an arm of the experiment")
+	}
+
+	return v.Err()
+}
+
+// checkExists adds a field error when the referenced entity is missing. Fields
+// that are already invalid are not looked up.
+func checkExists(ctx context.Context, v *validation.Validator, field string, id int, exists existsFunc) error {
+	if !v.FieldValid(field) {
+		return nil
+	}
+
+	ok, err := exists(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	v.Check(ok, field, "does not exist")
+
+	return nil
+}
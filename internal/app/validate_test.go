@@ -0,0 +1,243 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+	"github.com/otus-murashko/banners-rotation/internal/validation"
+)
+
+// checkFields fails the test unless err is a validation error of exactly the
+// fields, or nil when no fields are wanted.
+func checkFields(t *testing.T, err error, want map[string]string) {
+	t.Helper()
+
+	if len(want) == 0 {
+		if err != nil {
+			t.Errorf("error = %v, want nil", err)
+		}
+		return
+	}
+
+	if apperror.KindOf(err) != apperror.KindValidation {
+		t.Fatalf("error = %v, want a validation error", err)
+	}
+	if got := apperror.FieldsOf(err); !maps.Equal(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestValidateBanner(t *testing.T) {
+	tests := []struct {
+		name   string
+		banner storage.Banner
+		want   map[string]string
+	}{
+		{name: "valid", banner: storage.Banner{Descr: "banner"}},
+		{name: "cpc", banner: storage.Banner{Descr: "banner", Pricing: storage.PricingCPC, Bid: 0.5}},
+		{name: "blank descr", banner: storage.Banner{Descr: " "}, want: map[string]string{"Descr": "must not be empty"}},
+		{
+			name:   "unknown pricing and negative bid",
+			banner: storage.Banner{Descr: "banner", Pricing: "cpv", Bid: -1},
+			want:   map[string]string{"Pricing": "must be one of cpm, cpc, cpa", "Bid": "must not be negative"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFields(t, validateBanner(tt.banner), tt.want)
+		})
+	}
+}
+
+func TestValidateConversion(t *testing.T) {
+	tests := []struct {
+		name string
+		conv storage.Conversion
+		want map[string]string
+	}{
+		{name: "valid", conv: storage.Conversion{ImpressionID: 1, Value: 9.5}},
+		{name: "without value", conv: storage.Conversion{ImpressionID: 1}},
+		{name: "no impression", want: map[string]string{"ImpressionID": "must be positive"}},
+		{
+			name: "negative impression and value",
+			conv: storage.Conversion{ImpressionID: -1, Value: -0.5},
+			want: map[string]string{"ImpressionID": "must be positive", "Value": "must not be negative"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFields(t, validateConversion(tt.conv), tt.want)
+		})
+	}
+}
+
+func TestValidateRotationRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		slotID  int
+		groupID int
+		want    map[string]string
+	}{
+		{name: "valid", slotID: 1, groupID: 2},
+		{name: "group from attributes", slotID: 1},
+		{name: "no slot", groupID: 1, want: map[string]string{"slot_id": "must be positive"}},
+		{
+			name:   "negative ids",
+			slotID: -1, groupID: -1,
+			want: map[string]string{"slot_id": "must be positive", "group_id": "must not be negative"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFields(t, validateRotationRequest(tt.slotID, tt.groupID), tt.want)
+		})
+	}
+}
+
+func TestCheckExists(t *testing.T) {
+	errDB := errors.New("connection refused")
+
+	tests := []struct {
+		name       string
+		invalid    bool
+		exists     bool
+		err        error
+		wantErr    error
+		wantFields map[string]string
+		wantLookup bool
+	}{
+		{name: "exists", exists: true, wantLookup: true},
+		{name: "missing", wantFields: map[string]string{"SlotID": "does not exist"}, wantLookup: true},
+		{name: "invalid field is not looked up", invalid: true, wantFields: map[string]string{"SlotID": "must be positive"}},
+		{name: "storage error", err: errDB, wantErr: errDB, wantLookup: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validation.New()
+			v.Check(!tt.invalid, "SlotID", "must be positive")
+
+			looked := false
+			err := checkExists(context.Background(), v, "SlotID", 1, func(context.Context, int) (bool, error) {
+				looked = true
+				return tt.exists, tt.err
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkExists() = %v, want %v", err, tt.wantErr)
+			}
+			if looked != tt.wantLookup {
+				t.Errorf("looked up = %v, want %v", looked, tt.wantLookup)
+			}
+			if tt.wantErr == nil {
+				checkFields(t, v.Err(), tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestValidateRotationsRequest(t *testing.T) {
+	tooMany := make([]int, maxBatchSlots+1)
+	for i := range tooMany {
+		tooMany[i] = i + 1
+	}
+
+	tests := []struct {
+		name    string
+		slotIDs []int
+		groupID int
+		want    map[string]string
+	}{
+		{name: "valid", slotIDs: []int{1, 2, 3}, groupID: 1},
+		{name: "empty", want: map[string]string{"SlotIDs": "must not be empty"}},
+		{name: "too many", slotIDs: tooMany, want: map[string]string{"SlotIDs": "must not contain more than 50 slots"}},
+		{name: "not positive", slotIDs: []int{1, 0}, want: map[string]string{"SlotIDs": "must be positive"}},
+		{name: "duplicates", slotIDs: []int{1, 2, 1}, want: map[string]string{"SlotIDs": "must not contain duplicates"}},
+		{name: "negative group", slotIDs: []int{1}, groupID: -1, want: map[string]string{"GroupID": "must not be negative"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFields(t, validateRotationsRequest(tt.slotIDs, tt.groupID), tt.want)
+		})
+	}
+}
+
+func TestValidateEvents(t *testing.T) {
+	show := storage.Event{Type: storage.EventShow, BannerID: 1, SlotID: 2, SosialGroupID: 3}
+	click := storage.Event{Type: storage.EventClick, BannerID: 1, SlotID: 2, SosialGroupID: 3, ExperimentID: 4, Arm: "a"}
+
+	tests := []struct {
+		name   string
+		events []storage.Event
+		want   map[string]string
+	}{
+		{name: "valid", events: []storage.Event{show, click}},
+		{name: "empty", want: map[string]string{"Events": "must not be empty"}},
+		{
+			name:   "too many",
+			events: make([]storage.Event, MaxBatchEvents+1),
+			want:   map[string]string{"Events": "must not contain more than 10000 events"},
+		},
+		{
+			name:   "errors by index",
+			events: []storage.Event{show, {Type: "view", BannerID: 1, SlotID: 2, SosialGroupID: 3}, {Type: storage.EventShow}},
+			want: map[string]string{
+				"Events[1].Type":          "must be one of show, click",
+				"Events[2].BannerID":      "must be positive",
+				"Events[2].SlotID":        "must be positive",
+				"Events[2].SosialGroupID": "must be positive",
+			},
+		},
+		{
+			name:   "experiment without arm",
+			events: []storage.Event{{Type: storage.EventClick, BannerID: 1, SlotID: 2, SosialGroupID: 3, ExperimentID: 4}},
+			want:   map[string]string{"Events[0].Arm": "is required with ExperimentID"},
+		},
+		{
+			name:   "negative ids",
+			events: []storage.Event{{Type: storage.EventClick, BannerID: 1, SlotID: 2, SosialGroupID: 3, ExperimentID: -1, Arm: "a", ImpressionID: -1}},
+			want: map[string]string{
+				"Events[0].ExperimentID": "must not be negative",
+				"Events[0].ImpressionID": "must not be negative",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFields(t, validateEvents(tt.events), tt.want)
+		})
+	}
+}
+
+func TestValidateIdempotencyKey(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want map[string]string
+	}{
+		{name: "valid", key: "3f1c-42"},
+		{name: "blank", key: "  ", want: map[string]string{"Idempotency-Key": "must not be empty"}},
+		{name: "longest", key: strings.Repeat("k", maxIdempotencyKeyLen)},
+		{
+			name: "too long",
+			key:  strings.Repeat("k", maxIdempotencyKeyLen+1),
+			want: map[string]string{"Idempotency-Key": "must not be longer than 255 characters"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFields(t, validateIdempotencyKey(tt.key), tt.want)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Kind is the machine-readable class of an error returned to API clients.
+type Kind string
+
+const (
+	KindNotFound         Kind = "not_found"
+	KindConflict         Kind = "conflict"
+	KindValidation       Kind = "validation"
+	KindUnauthenticated  Kind = "unauthenticated"
+	KindPermissionDenied Kind = "permission_denied"
+	KindRateLimited      Kind = "rate_limited"
+	KindUnavailable      Kind = "unavailable"
+	KindInternal         Kind = "internal"
+)
+
+// Error is a domain error. Message and Fields are safe to show to clients,
+// Err keeps the underlying cause for logs.
+type Error struct {
+	Kind    Kind
+	Message string
+	Fields  map[string]string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %v", e.Message, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func New(kind Kind, format string, args ...any) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+func Wrap(kind Kind, err error, format string, args ...any) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+func NotFound(format string, args ...any) error {
+	return New(KindNotFound, format, args...)
+}
+
+func Conflict(format string, args ...any) error {
+	return New(KindConflict, format, args...)
+}
+
+func Validation(format string, args ...any) error {
+	return New(KindValidation, format, args...)
+}
+
+// InvalidFields is a validation error with a message per invalid field.
+func InvalidFields(fields map[string]string) error {
+	return &Error{Kind: KindValidation, Message: "request validation failed", Fields: fields}
+}
+
+func Unauthenticated(format string, args ...any) error {
+	return New(KindUnauthenticated, format, args...)
+}
+
+func PermissionDenied(format string, args ...any) error {
+	return New(KindPermissionDenied, format, args...)
+}
+
+// RateLimitedError asks the client to retry after RetryAfter.
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("too many requests, retry after %s", e.RetryAfter)
+}
+
+func RateLimited(retryAfter time.Duration) error {
+	return &Error{Kind: KindRateLimited, Message: "too many requests", Err: &RateLimitedError{RetryAfter: retryAfter}}
+}
+
+// RetryAfterOf returns the time a rate limited client has to wait.
+func RetryAfterOf(err error) (time.Duration, bool) {
+	var limited *RateLimitedError
+	if errors.As(err, &limited) {
+		return limited.RetryAfter, true
+	}
+	return 0, false
+}
+
+func Unavailable(format string, args ...any) error {
+	return New(KindUnavailable, format, args...)
+}
+
+// Canceled returns an unavailable error when the request context is done.
+func Canceled(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return Wrap(KindUnavailable, err, "request is cancelled or timed out")
+	}
+	return nil
+}
+
+// KindOf returns the kind of the first domain error in the err chain.
+// Errors without a kind are internal.
+func KindOf(err error) Kind {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Kind
+	}
+	return KindInternal
+}
+
+// MessageOf returns the client-safe message of err. Internal errors never
+// expose their text.
+func MessageOf(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) && appErr.Kind != KindInternal {
+		return appErr.Message
+	}
+	return "internal error"
+}
+
+// FieldsOf returns the field-level messages of a validation error.
+func FieldsOf(err error) map[string]string {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Fields
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"slices"
+
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+// Scope is the part of the API an API key gives access to.
+type Scope string
+
+const (
+	// ScopeRead allows the statistic and the reports.
+	ScopeRead Scope = "read"
+	// ScopeTrack allows the banner selection, shows, clicks and conversions.
+	ScopeTrack Scope = "track"
+	// ScopeAdmin allows everything, including the banners, slots, groups,
+	// rotations and experiments management.
+	ScopeAdmin Scope = "admin"
+)
+
+var Scopes = []Scope{ScopeRead, ScopeTrack, ScopeAdmin}
+
+const keyPrefix = "br_"
+
+// GenerateKey returns a new random API key. Only its hash is stored.
+func GenerateKey() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// HashKey returns the hash the key is stored and looked up by. The keys are
+// random, so a plain SHA-256 is enough.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func ValidScope(scope string) bool {
+	return slices.Contains(Scopes, Scope(scope))
+}
+
+// Allows reports whether the key scopes grant the required one.
+func Allows(scopes []string, required Scope) bool {
+	return slices.Contains(scopes, string(required)) || slices.Contains(scopes, string(ScopeAdmin))
+}
+
+type keyContextKey struct{}
+
+func WithKey(ctx context.Context, key storage.APIKey) context.Context {
+	return context.WithValue(ctx, keyContextKey{}, key)
+}
+
+// KeyFrom returns the API key the request is authenticated with.
+func KeyFrom(ctx context.Context) (storage.APIKey, bool) {
+	key, ok := ctx.Value(keyContextKey{}).(storage.APIKey)
+	return key, ok
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   []string
+		required Scope
+		want     bool
+	}{
+		{name: "same scope", scopes: []string{"read"}, required: ScopeRead, want: true},
+		{name: "one of the scopes", scopes: []string{"read", "track"}, required: ScopeTrack, want: true},
+		{name: "another scope", scopes: []string{"read"}, required: ScopeTrack},
+		{name: "admin grants read", scopes: []string{"admin"}, required: ScopeRead, want: true},
+		{name: "admin grants track", scopes: []string{"admin"}, required: ScopeTrack, want: true},
+		{name: "admin scope", scopes: []string{"track"}, required: ScopeAdmin},
+		{name: "scope of another case", scopes: []string{"READ"}, required: ScopeRead},
+		{name: "no scopes", required: ScopeRead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Allows(tt.scopes, tt.required); got != tt.want {
+				t.Errorf("Allows(%v, %q) = %v, want %v", tt.scopes, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "", want: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{key: "abc", want: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+	}
+
+	for _, tt := range tests {
+		if got := HashKey(tt.key); got != tt.want {
+			t.Errorf("HashKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateKey(t *testing.T) {
+	first, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(first, keyPrefix) {
+		t.Errorf("GenerateKey() = %q, want the %q prefix", first, keyPrefix)
+	}
+	if first == second {
+		t.Errorf("GenerateKey() returned %q twice", first)
+	}
+	if HashKey(first) == HashKey(second) {
+		t.Errorf("keys %q and %q have the same hash", first, second)
+	}
+}
+
+func TestValidScope(t *testing.T) {
+	for _, scope := range []string{"read", "track", "admin"} {
+		if !ValidScope(scope) {
+			t.Errorf("ValidScope(%q) = false, want true", scope)
+		}
+	}
+	for _, scope := range []string{"", "Admin", "write"} {
+		if ValidScope(scope) {
+			t.Errorf("ValidScope(%q) = true, want false", scope)
+		}
+	}
+}
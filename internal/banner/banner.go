@@ -4,67 +4,97 @@ import (
 	"context"
 	"math"
 
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/segment"
 	"github.com/otus-murashko/banners-rotation/internal/storage"
 )
 
 type BannerSelector interface {
-	GetBanner(ctx context.Context, slotID, sGroupID int) (storage.Banner, error)
+	GetBanner(ctx context.Context, slotID, sGroupID int, attrs segment.Attributes) (storage.Banner, error)
+	// Pick chooses one of the candidates without touching the storage, the
+	// caller records the show. It returns false when there are no candidates.
+	Pick(candidates []storage.SlotBanner) (storage.SlotBanner, bool)
 }
 
 type BannerBanditSelector struct {
-	db storage.Storage
+	db        storage.Storage
+	objective Objective
 }
 
-func NewBannerBanditSelector(db storage.Storage) BannerBanditSelector {
-	return BannerBanditSelector{db: db}
+func NewBannerBanditSelector(db storage.Storage, objective Objective) BannerBanditSelector {
+	return BannerBanditSelector{db: db, objective: objective}
 }
 
-func (bs BannerBanditSelector) GetBanner(ctx context.Context, slotID, sGroupID int) (storage.Banner, error) {
+func (bs BannerBanditSelector) GetBanner(ctx context.Context, slotID, sGroupID int, attrs segment.Attributes) (storage.Banner, error) {
 
-	// get all statistic for the banners and social group
+	// get all statistic for the targeted banners and social group
 
-	bannerIDs, err := bs.db.GetBannersBySlot(ctx, slotID)
+	stats, err := getSlotStat(ctx, bs.db, slotID, sGroupID, attrs)
 
 	if err != nil {
 		return storage.Banner{}, err
 	}
 
-	stats, err := bs.db.GetBannersStat(ctx, slotID, sGroupID, bannerIDs)
+	best, ok := bs.Pick(statCandidates(stats))
+	if !ok {
+		return storage.Banner{}, nil
+	}
 
-	if err != nil {
+	// do not count the show of a banner nobody will see
+	if err := apperror.Canceled(ctx); err != nil {
 		return storage.Banner{}, err
 	}
 
+	bs.db.UpdateShowStat(ctx, best.Stat)
+
+	return storage.Banner{ID: best.Stat.BannerID}, nil
+
+}
+
+func (bs BannerBanditSelector) Pick(candidates []storage.SlotBanner) (storage.SlotBanner, bool) {
+
 	totalShowsCount := 0
 
 	// count total shows
-	for _, stat := range stats {
-		totalShowsCount += stat.ShowsCount
+	for _, c := range candidates {
+		totalShowsCount += c.Stat.ShowsCount
 	}
 
 	lnFormulaPart := 2 * math.Log(float64(totalShowsCount))
 
-	bestStat := storage.Statistic{}
+	best := storage.SlotBanner{}
 	var bestBannerWeight float64
+	picked := false
 
-	for _, stat := range stats {
-		totalShowsCount += stat.ShowsCount
+	for _, c := range candidates {
+		stat := c.Stat
 		if stat.ShowsCount == 0 {
-			bestStat = stat
-			break
+			return c, true
 		}
 
-		weight := float64(stat.ClicksCount)/float64(stat.ShowsCount) +
+		successes, reward := bs.objective.successes(stat)
+		weight := float64(successes)*reward/float64(stat.ShowsCount) +
 			math.Sqrt(2*lnFormulaPart/float64(stat.ShowsCount))
 
-		if weight > float64(bestBannerWeight) {
+		// the first candidate is taken even when all the weights are zero
+		if !picked || weight > bestBannerWeight {
 			bestBannerWeight = weight
-			bestStat = stat
+			best = c
+			picked = true
 		}
 	}
 
-	bs.db.UpdateShowStat(ctx, bestStat)
-
-	return storage.Banner{ID: bestStat.BannerID}, nil
+	return best, picked
+}
 
+// statCandidates wraps the statistic of the slot banners for Pick.
+func statCandidates(stats []storage.Statistic) []storage.SlotBanner {
+	candidates := make([]storage.SlotBanner, 0, len(stats))
+	for _, stat := range stats {
+		candidates = append(candidates, storage.SlotBanner{
+			Banner: storage.Banner{ID: stat.BannerID},
+			Stat:   stat,
+		})
+	}
+	return candidates
 }
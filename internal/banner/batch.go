@@ -0,0 +1,49 @@
+package banner
+
+import (
+	"github.com/otus-murashko/banners-rotation/internal/segment"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+// PickBatch chooses a banner for every slot from the candidates of all the
+// slots. Slots are served in the given order and, when unique is set, a banner
+// picked for one slot is not offered to the next ones. Slots without eligible
+// banners are missing from the result. selectorFor returns the selector of a
+// slot.
+func PickBatch(selectorFor func(slotID int) BannerSelector, candidates []storage.SlotBanner, slotIDs []int,
+	attrs segment.Attributes, unique bool,
+) map[int]storage.SlotBanner {
+	bySlot := make(map[int][]storage.SlotBanner, len(slotIDs))
+	for _, c := range candidates {
+		if matchTargeting(c.Targeting, attrs) {
+			bySlot[c.Stat.SlotID] = append(bySlot[c.Stat.SlotID], c)
+		}
+	}
+
+	picked := make(map[int]storage.SlotBanner, len(slotIDs))
+	used := make(map[int]struct{}, len(slotIDs))
+
+	for _, slotID := range slotIDs {
+		slotCandidates := bySlot[slotID]
+
+		if unique {
+			free := make([]storage.SlotBanner, 0, len(slotCandidates))
+			for _, c := range slotCandidates {
+				if _, ok := used[c.Banner.ID]; !ok {
+					free = append(free, c)
+				}
+			}
+			slotCandidates = free
+		}
+
+		best, ok := selectorFor(slotID).Pick(slotCandidates)
+		if !ok {
+			continue
+		}
+
+		picked[slotID] = best
+		used[best.Banner.ID] = struct{}{}
+	}
+
+	return picked
+}
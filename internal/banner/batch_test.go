@@ -0,0 +1,153 @@
+package banner
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/segment"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+// firstSelector picks the first candidate, so the tests see which candidates
+// were offered.
+type firstSelector struct{}
+
+func (firstSelector) GetBanner(context.Context, int, int, segment.Attributes) (storage.Banner, error) {
+	return storage.Banner{}, nil
+}
+
+func (firstSelector) Pick(candidates []storage.SlotBanner) (storage.SlotBanner, bool) {
+	if len(candidates) == 0 {
+		return storage.SlotBanner{}, false
+	}
+	return candidates[0], true
+}
+
+func slotBanner(slotID, bannerID int, countries ...string) storage.SlotBanner {
+	return storage.SlotBanner{
+		Banner:    storage.Banner{ID: bannerID},
+		Targeting: storage.Targeting{Countries: countries},
+		Stat:      storage.Statistic{BannerID: bannerID, SlotID: slotID, ShowsCount: 10},
+	}
+}
+
+func TestPickBatch(t *testing.T) {
+	candidates := []storage.SlotBanner{
+		slotBanner(1, 10),
+		slotBanner(1, 11),
+		slotBanner(2, 10),
+		slotBanner(2, 12, "DE"),
+		slotBanner(3, 10),
+	}
+
+	tests := []struct {
+		name    string
+		slotIDs []int
+		attrs   segment.Attributes
+		unique  bool
+		want    map[int]int
+	}{
+		{
+			name:    "same banner for every slot",
+			slotIDs: []int{1, 2, 3},
+			want:    map[int]int{1: 10, 2: 10, 3: 10},
+		},
+		{
+			name:    "unique banners",
+			slotIDs: []int{1, 2, 3},
+			attrs:   segment.Attributes{Country: "DE"},
+			unique:  true,
+			want:    map[int]int{1: 10, 2: 12},
+		},
+		{
+			name:    "slots are served in the given order",
+			slotIDs: []int{3, 1},
+			unique:  true,
+			want:    map[int]int{3: 10, 1: 11},
+		},
+		{
+			name:    "targeted banner is left out",
+			slotIDs: []int{2},
+			attrs:   segment.Attributes{Country: "RU"},
+			unique:  true,
+			want:    map[int]int{2: 10},
+		},
+		{
+			name:    "slot without candidates",
+			slotIDs: []int{4, 1},
+			want:    map[int]int{1: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			picked := PickBatch(func(int) BannerSelector { return firstSelector{} },
+				candidates, tt.slotIDs, tt.attrs, tt.unique)
+
+			got := make(map[int]int, len(picked))
+			for slotID, c := range picked {
+				got[slotID] = c.Banner.ID
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("PickBatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPickBatchSelectorPerSlot(t *testing.T) {
+	candidates := []storage.SlotBanner{slotBanner(1, 10), slotBanner(2, 10)}
+
+	asked := make([]int, 0)
+	PickBatch(func(slotID int) BannerSelector {
+		asked = append(asked, slotID)
+		return firstSelector{}
+	}, candidates, []int{2, 1}, segment.Attributes{}, false)
+
+	if len(asked) != 2 || asked[0] != 2 || asked[1] != 1 {
+		t.Errorf("selectors asked for slots %v, want [2 1]", asked)
+	}
+}
+
+func TestBanditPick(t *testing.T) {
+	stat := func(bannerID, shows, clicks int) storage.SlotBanner {
+		return storage.SlotBanner{
+			Banner: storage.Banner{ID: bannerID},
+			Stat:   storage.Statistic{BannerID: bannerID, ShowsCount: shows, ClicksCount: clicks},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		candidates []storage.SlotBanner
+		wantID     int
+		wantOK     bool
+	}{
+		{name: "no candidates"},
+		{
+			name:       "banner without shows first",
+			candidates: []storage.SlotBanner{stat(1, 100, 50), stat(2, 0, 0)},
+			wantID:     2, wantOK: true,
+		},
+		{
+			name:       "highest weight",
+			candidates: []storage.SlotBanner{stat(1, 1000, 10), stat(2, 1000, 100), stat(3, 1000, 50)},
+			wantID:     2, wantOK: true,
+		},
+		{
+			name:       "all weights are zero",
+			candidates: []storage.SlotBanner{stat(1, 1, 0)},
+			wantID:     1, wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NewBannerBanditSelector(nil, ObjectiveCTR).Pick(tt.candidates)
+			if got.Banner.ID != tt.wantID || ok != tt.wantOK {
+				t.Errorf("Pick() = (%d, %v), want (%d, %v)", got.Banner.ID, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package banner
+
+import (
+	"fmt"
+
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+// Objective is the reward a selector maximises per show.
+type Objective string
+
+const (
+	ObjectiveCTR   Objective = "ctr"
+	ObjectiveCVR   Objective = "cvr"
+	ObjectiveValue Objective = "value"
+)
+
+func ParseObjective(name string) (Objective, error) {
+	switch Objective(name) {
+	case "":
+		return ObjectiveCTR, nil
+	case ObjectiveCTR, ObjectiveCVR, ObjectiveValue:
+		return Objective(name), nil
+	default:
+		return "", fmt.Errorf("unknown selection objective %q", name)
+	}
+}
+
+// successes returns the number of rewarded shows and the reward of a single
+// success. The value objective falls back to the conversion rate until the
+// first conversion brings a value.
+func (o Objective) successes(stat storage.Statistic) (int, float64) {
+	switch o {
+	case ObjectiveCVR:
+		return stat.Conversions, 1
+	case ObjectiveValue:
+		if stat.Conversions == 0 || stat.ConversionValue <= 0 {
+			return stat.Conversions, 1
+		}
+		return stat.Conversions, stat.ConversionValue / float64(stat.Conversions)
+	default:
+		return stat.ClicksCount, 1
+	}
+}
@@ -0,0 +1,68 @@
+package banner
+
+import (
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+func TestParseObjective(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Objective
+		wantErr bool
+	}{
+		{name: "", want: ObjectiveCTR},
+		{name: "ctr", want: ObjectiveCTR},
+		{name: "cvr", want: ObjectiveCVR},
+		{name: "value", want: ObjectiveValue},
+		{name: "CVR", wantErr: true},
+		{name: "clicks", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseObjective(tt.name)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseObjective(%q) = (%q, %v), want %q, wantErr %v", tt.name, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestObjectiveSuccesses(t *testing.T) {
+	stat := storage.Statistic{ShowsCount: 100, ClicksCount: 10, Conversions: 4, ConversionValue: 20}
+
+	tests := []struct {
+		name       string
+		objective  Objective
+		stat       storage.Statistic
+		wantCount  int
+		wantReward float64
+	}{
+		{name: "ctr counts clicks", objective: ObjectiveCTR, stat: stat, wantCount: 10, wantReward: 1},
+		{name: "cvr counts conversions", objective: ObjectiveCVR, stat: stat, wantCount: 4, wantReward: 1},
+		{name: "value rewards the mean value", objective: ObjectiveValue, stat: stat, wantCount: 4, wantReward: 5},
+		{
+			name:      "value without conversions",
+			objective: ObjectiveValue,
+			stat:      storage.Statistic{ShowsCount: 100, ClicksCount: 10},
+			wantCount: 0, wantReward: 1,
+		},
+		{
+			name:      "value of conversions without a value",
+			objective: ObjectiveValue,
+			stat:      storage.Statistic{ShowsCount: 100, ClicksCount: 10, Conversions: 3},
+			wantCount: 3, wantReward: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, reward := tt.objective.successes(tt.stat)
+			if count != tt.wantCount || reward != tt.wantReward {
+				t.Errorf("successes() = (%d, %g), want (%d, %g)", count, reward, tt.wantCount, tt.wantReward)
+			}
+		})
+	}
+}
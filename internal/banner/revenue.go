@@ -0,0 +1,160 @@
+package banner
+
+import (
+	"context"
+	"math"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/segment"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+// BannerRevenueSelector picks the banner with the highest expected revenue
+// per show: bid × optimistic CTR for CPC, bid × optimistic conversion rate
+// for CPA and bid / 1000 for CPM banners.
+type BannerRevenueSelector struct {
+	db storage.Storage
+}
+
+func NewBannerRevenueSelector(db storage.Storage) BannerRevenueSelector {
+	return BannerRevenueSelector{db: db}
+}
+
+func (rs BannerRevenueSelector) GetBanner(ctx context.Context, slotID, sGroupID int, attrs segment.Attributes) (storage.Banner, error) {
+
+	stats, err := getSlotStat(ctx, rs.db, slotID, sGroupID, attrs)
+	if err != nil || len(stats) == 0 {
+		return storage.Banner{}, err
+	}
+
+	bannerIDs := make([]int, 0, len(stats))
+	for _, stat := range stats {
+		bannerIDs = append(bannerIDs, stat.BannerID)
+	}
+
+	banners, err := rs.db.GetBanners(ctx, bannerIDs)
+	if err != nil {
+		return storage.Banner{}, err
+	}
+
+	pricing := make(map[int]storage.Banner, len(banners))
+	for _, banner := range banners {
+		pricing[banner.ID] = banner
+	}
+
+	candidates := statCandidates(stats)
+	for i := range candidates {
+		candidates[i].Banner = pricing[candidates[i].Stat.BannerID]
+	}
+
+	best, _ := rs.Pick(candidates)
+
+	// do not count the show of a banner nobody will see
+	if err := apperror.Canceled(ctx); err != nil {
+		return storage.Banner{}, err
+	}
+
+	rs.db.UpdateShowStat(ctx, best.Stat)
+
+	// the pricing of the advertiser is not sent to the page
+	return storage.Banner{ID: best.Banner.ID, Descr: best.Banner.Descr}, nil
+}
+
+// Pick expects the candidates to carry the banner pricing.
+func (rs BannerRevenueSelector) Pick(candidates []storage.SlotBanner) (storage.SlotBanner, bool) {
+
+	totalShowsCount := 0
+	for _, c := range candidates {
+		totalShowsCount += c.Stat.ShowsCount
+	}
+
+	best := storage.SlotBanner{}
+	bestRevenue := -1.0
+
+	for _, c := range candidates {
+		revenue := expectedRevenue(c.Banner, c.Stat, totalShowsCount)
+
+		if revenue > bestRevenue {
+			bestRevenue = revenue
+			best = c
+		}
+	}
+
+	return best, len(candidates) > 0
+}
+
+// expectedRevenue estimates the revenue of a single show with the UCB1 upper
+// bound of the click or conversion rate, so unexplored banners still get shows.
+func expectedRevenue(banner storage.Banner, stat storage.Statistic, totalShowsCount int) float64 {
+	if banner.Pricing == storage.PricingCPM || banner.Pricing == "" {
+		return banner.Bid / 1000
+	}
+
+	if stat.ShowsCount == 0 {
+		return math.Inf(1)
+	}
+
+	successes := stat.ClicksCount
+	if banner.Pricing == storage.PricingCPA {
+		successes = stat.Conversions
+	}
+
+	rate := float64(successes)/float64(stat.ShowsCount) +
+		math.Sqrt(2*math.Log(float64(totalShowsCount))/float64(stat.ShowsCount))
+
+	return banner.Bid * math.Min(rate, 1)
+}
+
+// Revenue is the amount earned by a banner for its shows, clicks and conversions.
+func Revenue(pricing string, bid float64, shows, clicks, conversions int) float64 {
+	switch pricing {
+	case storage.PricingCPC:
+		return bid * float64(clicks)
+	case storage.PricingCPA:
+		return bid * float64(conversions)
+	default:
+		return bid * float64(shows) / 1000
+	}
+}
+
+type BannerRevenue struct {
+	BannerID    int
+	Pricing     string
+	Bid         float64
+	ShowsCount  int
+	ClicksCount int
+	Conversions int
+	Revenue     float64
+}
+
+type SlotRevenue struct {
+	SlotID  int
+	Revenue float64
+	Banners []BannerRevenue
+}
+
+// RevenueReport groups the banner revenue by slot. stats must be ordered by slot.
+func RevenueReport(stats []storage.RevenueStatistic) []SlotRevenue {
+	reports := make([]SlotRevenue, 0)
+
+	for _, stat := range stats {
+		if len(reports) == 0 || reports[len(reports)-1].SlotID != stat.SlotID {
+			reports = append(reports, SlotRevenue{SlotID: stat.SlotID, Banners: make([]BannerRevenue, 0)})
+		}
+		slot := &reports[len(reports)-1]
+
+		revenue := Revenue(stat.Pricing, stat.Bid, stat.ShowsCount, stat.ClicksCount, stat.Conversions)
+		slot.Revenue += revenue
+		slot.Banners = append(slot.Banners, BannerRevenue{
+			BannerID:    stat.BannerID,
+			Pricing:     stat.Pricing,
+			Bid:         stat.Bid,
+			ShowsCount:  stat.ShowsCount,
+			ClicksCount: stat.ClicksCount,
+			Conversions: stat.Conversions,
+			Revenue:     revenue,
+		})
+	}
+
+	return reports
+}
@@ -0,0 +1,163 @@
+package banner
+
+import (
+	"math"
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+func TestExpectedRevenue(t *testing.T) {
+	tests := []struct {
+		name   string
+		banner storage.Banner
+		stat   storage.Statistic
+		total  int
+		want   float64
+	}{
+		{
+			name:   "cpm pays per thousand shows",
+			banner: storage.Banner{Pricing: storage.PricingCPM, Bid: 3},
+			stat:   storage.Statistic{ShowsCount: 100, ClicksCount: 50},
+			total:  1000,
+			want:   0.003,
+		},
+		{
+			name:   "no pricing is cpm",
+			banner: storage.Banner{Bid: 5},
+			total:  1000,
+			want:   0.005,
+		},
+		{
+			name:   "cpc without shows is explored first",
+			banner: storage.Banner{Pricing: storage.PricingCPC, Bid: 1},
+			total:  1000,
+			want:   math.Inf(1),
+		},
+		{
+			name:   "cpc by the upper bound of ctr",
+			banner: storage.Banner{Pricing: storage.PricingCPC, Bid: 2},
+			stat:   storage.Statistic{ShowsCount: 100, ClicksCount: 10, Conversions: 9},
+			total:  1000,
+			want:   0.9433844,
+		},
+		{
+			name:   "cpa by the upper bound of the conversion rate",
+			banner: storage.Banner{Pricing: storage.PricingCPA, Bid: 10},
+			stat:   storage.Statistic{ShowsCount: 100, ClicksCount: 10, Conversions: 2},
+			total:  1000,
+			want:   3.9169222,
+		},
+		{
+			name:   "rate is capped at one",
+			banner: storage.Banner{Pricing: storage.PricingCPC, Bid: 2},
+			stat:   storage.Statistic{ShowsCount: 1, ClicksCount: 1},
+			total:  1000,
+			want:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := expectedRevenue(tt.banner, tt.stat, tt.total)
+			if got != tt.want && math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("expectedRevenue() = %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevenue(t *testing.T) {
+	tests := []struct {
+		pricing string
+		want    float64
+	}{
+		{pricing: storage.PricingCPM, want: 4},
+		{pricing: "", want: 4},
+		{pricing: storage.PricingCPC, want: 40},
+		{pricing: storage.PricingCPA, want: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pricing, func(t *testing.T) {
+			if got := Revenue(tt.pricing, 2, 2000, 20, 3); got != tt.want {
+				t.Errorf("Revenue(%q) = %g, want %g", tt.pricing, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevenueReport(t *testing.T) {
+	stats := []storage.RevenueStatistic{
+		{SlotID: 1, BannerID: 1, Pricing: storage.PricingCPM, Bid: 2, ShowsCount: 1000},
+		{SlotID: 1, BannerID: 2, Pricing: storage.PricingCPC, Bid: 0.5, ShowsCount: 100, ClicksCount: 4},
+		{SlotID: 2, BannerID: 1, Pricing: storage.PricingCPA, Bid: 10, ShowsCount: 100, Conversions: 1},
+	}
+
+	got := RevenueReport(stats)
+	if len(got) != 2 {
+		t.Fatalf("RevenueReport() returned %d slots, want 2", len(got))
+	}
+
+	want := []struct {
+		slotID  int
+		revenue float64
+		banners int
+	}{
+		{slotID: 1, revenue: 4, banners: 2},
+		{slotID: 2, revenue: 10, banners: 1},
+	}
+	for i, w := range want {
+		if got[i].SlotID != w.slotID || got[i].Revenue != w.revenue || len(got[i].Banners) != w.banners {
+			t.Errorf("RevenueReport()[%d] = slot %d, revenue %g, %d banners, want slot %d, revenue %g, %d banners",
+				i, got[i].SlotID, got[i].Revenue, len(got[i].Banners), w.slotID, w.revenue, w.banners)
+		}
+	}
+
+	if len(RevenueReport(nil)) != 0 {
+		t.Error("RevenueReport(nil) is not empty")
+	}
+}
+
+func TestRevenueSelectorPick(t *testing.T) {
+	candidate := func(id int, pricing string, bid float64, shows, clicks int) storage.SlotBanner {
+		return storage.SlotBanner{
+			Banner: storage.Banner{ID: id, Pricing: pricing, Bid: bid},
+			Stat:   storage.Statistic{BannerID: id, ShowsCount: shows, ClicksCount: clicks},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		candidates []storage.SlotBanner
+		wantID     int
+		wantOK     bool
+	}{
+		{name: "no candidates"},
+		{
+			name: "highest cpm bid",
+			candidates: []storage.SlotBanner{
+				candidate(1, storage.PricingCPM, 1, 10, 0),
+				candidate(2, storage.PricingCPM, 3, 10, 0),
+			},
+			wantID: 2, wantOK: true,
+		},
+		{
+			name: "unexplored cpc banner",
+			candidates: []storage.SlotBanner{
+				candidate(1, storage.PricingCPM, 100, 10, 0),
+				candidate(2, storage.PricingCPC, 0.1, 0, 0),
+			},
+			wantID: 2, wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := BannerRevenueSelector{}.Pick(tt.candidates)
+			if got.Banner.ID != tt.wantID || ok != tt.wantOK {
+				t.Errorf("Pick() = (%d, %v), want (%d, %v)", got.Banner.ID, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package banner
+
+import (
+	"fmt"
+
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+const (
+	StrategyUCB1     = "ucb1"
+	StrategyThompson = "thompson"
+	StrategyRevenue  = "revenue"
+)
+
+// Strategies lists the selection strategies accepted by NewSelector.
+var Strategies = []string{StrategyUCB1, StrategyThompson, StrategyRevenue}
+
+func NewSelector(strategy string, objective Objective, db storage.Storage) (BannerSelector, error) {
+	switch strategy {
+	case StrategyUCB1:
+		return NewBannerBanditSelector(db, objective), nil
+	case StrategyThompson:
+		return NewBannerThompsonSelector(db, objective), nil
+	case StrategyRevenue:
+		return NewBannerRevenueSelector(db), nil
+	default:
+		return nil, fmt.Errorf("unknown selection strategy %q", strategy)
+	}
+}
@@ -0,0 +1,67 @@
+package banner
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/segment"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+// getSlotStat returns the statistic of the slot banners allowed to run for attrs.
+func getSlotStat(ctx context.Context, db storage.Storage, slotID, sGroupID int, attrs segment.Attributes) ([]storage.Statistic, error) {
+	rotations, err := db.GetSlotRotations(ctx, slotID)
+	if err != nil {
+		return nil, err
+	}
+
+	bannerIDs := make([]int, 0, len(rotations))
+	for _, rotation := range rotations {
+		if matchTargeting(rotation.Targeting, attrs) {
+			bannerIDs = append(bannerIDs, rotation.BannerID)
+		}
+	}
+
+	if len(bannerIDs) == 0 {
+		return []storage.Statistic{}, nil
+	}
+
+	if err = apperror.Canceled(ctx); err != nil {
+		return nil, err
+	}
+
+	return db.GetBannersStat(ctx, slotID, sGroupID, bannerIDs)
+}
+
+func matchTargeting(t storage.Targeting, attrs segment.Attributes) bool {
+	return matchValue(t.Countries, attrs.Country) &&
+		matchValue(t.Regions, attrs.Region) &&
+		matchValue(t.Devices, attrs.Device) &&
+		matchValue(t.OS, attrs.OS) &&
+		matchLanguage(t.Languages, attrs.Language)
+}
+
+func matchValue(allowed []string, value string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(allowed, func(a string) bool {
+		return strings.EqualFold(a, value)
+	})
+}
+
+// matchLanguage accepts "en-US" for the allowed "en" but not the other way round.
+func matchLanguage(allowed []string, lang string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	base, _, _ := strings.Cut(lang, "-")
+
+	return slices.ContainsFunc(allowed, func(a string) bool {
+		return strings.EqualFold(a, lang) || strings.EqualFold(a, base)
+	})
+}
@@ -0,0 +1,92 @@
+package banner
+
+import (
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/segment"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+func TestMatchTargeting(t *testing.T) {
+	tests := []struct {
+		name      string
+		targeting storage.Targeting
+		attrs     segment.Attributes
+		want      bool
+	}{
+		{
+			name:  "no targeting",
+			attrs: segment.Attributes{Country: "RU"},
+			want:  true,
+		},
+		{
+			name:      "country in any case",
+			targeting: storage.Targeting{Countries: []string{"ru", "by"}},
+			attrs:     segment.Attributes{Country: "RU"},
+			want:      true,
+		},
+		{
+			name:      "other country",
+			targeting: storage.Targeting{Countries: []string{"RU"}},
+			attrs:     segment.Attributes{Country: "DE"},
+		},
+		{
+			name:      "unknown country",
+			targeting: storage.Targeting{Countries: []string{"RU"}},
+		},
+		{
+			name: "all fields",
+			targeting: storage.Targeting{
+				Countries: []string{"RU"},
+				Regions:   []string{"MOW"},
+				Devices:   []string{"mobile"},
+				OS:        []string{"android"},
+				Languages: []string{"ru"},
+			},
+			attrs: segment.Attributes{Country: "RU", Region: "MOW", Device: "mobile", OS: "Android", Language: "ru-RU"},
+			want:  true,
+		},
+		{
+			name: "one field differs",
+			targeting: storage.Targeting{
+				Countries: []string{"RU"},
+				Devices:   []string{"mobile"},
+			},
+			attrs: segment.Attributes{Country: "RU", Device: "desktop"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchTargeting(tt.targeting, tt.attrs); got != tt.want {
+				t.Errorf("matchTargeting(%+v, %+v) = %v, want %v", tt.targeting, tt.attrs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchLanguage(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		lang    string
+		want    bool
+	}{
+		{name: "any language", lang: "en", want: true},
+		{name: "same language", allowed: []string{"en"}, lang: "en", want: true},
+		{name: "region of an allowed language", allowed: []string{"en"}, lang: "en-US", want: true},
+		{name: "language of an allowed region", allowed: []string{"en-US"}, lang: "en"},
+		{name: "other region", allowed: []string{"en-GB"}, lang: "en-US"},
+		{name: "same region in another case", allowed: []string{"en-us"}, lang: "EN-US", want: true},
+		{name: "other language", allowed: []string{"ru", "de"}, lang: "en-US"},
+		{name: "unknown language", allowed: []string{"en"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchLanguage(tt.allowed, tt.lang); got != tt.want {
+				t.Errorf("matchLanguage(%v, %q) = %v, want %v", tt.allowed, tt.lang, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package banner
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/segment"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+// BannerThompsonSelector picks the banner with the highest reward rate sampled
+// from the Beta(successes+1, shows-successes+1) posterior of every banner in
+// the slot.
+type BannerThompsonSelector struct {
+	db        storage.Storage
+	objective Objective
+}
+
+func NewBannerThompsonSelector(db storage.Storage, objective Objective) BannerThompsonSelector {
+	return BannerThompsonSelector{db: db, objective: objective}
+}
+
+func (ts BannerThompsonSelector) GetBanner(ctx context.Context, slotID, sGroupID int, attrs segment.Attributes) (storage.Banner, error) {
+
+	stats, err := getSlotStat(ctx, ts.db, slotID, sGroupID, attrs)
+
+	if err != nil {
+		return storage.Banner{}, err
+	}
+
+	best, ok := ts.Pick(statCandidates(stats))
+	if !ok {
+		return storage.Banner{}, nil
+	}
+
+	// do not count the show of a banner nobody will see
+	if err := apperror.Canceled(ctx); err != nil {
+		return storage.Banner{}, err
+	}
+
+	ts.db.UpdateShowStat(ctx, best.Stat)
+
+	return storage.Banner{ID: best.Stat.BannerID}, nil
+}
+
+func (ts BannerThompsonSelector) Pick(candidates []storage.SlotBanner) (storage.SlotBanner, bool) {
+
+	best := storage.SlotBanner{}
+	bestSample := -1.0
+
+	for _, c := range candidates {
+		successes, reward := ts.objective.successes(c.Stat)
+		failures := max(c.Stat.ShowsCount-successes, 0)
+		sample := betaSample(float64(successes+1), float64(failures+1)) * reward
+
+		if sample > bestSample {
+			bestSample = sample
+			best = c
+		}
+	}
+
+	return best, len(candidates) > 0
+}
+
+func betaSample(alpha, beta float64) float64 {
+	x := gammaSample(alpha)
+	y := gammaSample(beta)
+
+	return x / (x + y)
+}
+
+// gammaSample draws from Gamma(shape, 1) using the Marsaglia-Tsang method.
+func gammaSample(shape float64) float64 {
+	if shape < 1 {
+		return gammaSample(shape+1) * math.Pow(rand.Float64(), 1/shape)
+	}
+
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+
+	for {
+		x := rand.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rand.Float64()
+
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
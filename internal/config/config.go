@@ -1,14 +1,26 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"reflect"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Database DBConfig `yaml:"db"`
-	Server   Server   `yaml:"server"`
+	Database DBConfig     `yaml:"db"`
+	Server   Server       `yaml:"server"`
+	GRPC     GRPCServer   `yaml:"grpc"`
+	Segments Segmentation `yaml:"segmentation"`
+	Rotation Rotation     `yaml:"rotation"`
+	Fraud    Fraud        `yaml:"fraud"`
+	Metrics  Metrics      `yaml:"metrics"`
+	Logger   Logger       `yaml:"logger"`
+	Tracing  Tracing      `yaml:"tracing"`
 	//Broker   Broker   `yaml:broker` //TODO KAFKA??? or RMQ???
 }
 
@@ -19,9 +31,148 @@ type DBConfig struct {
 	Password string `yaml:"password"`
 	DBName   string `yaml:"dbName"`
 	InMemory bool   `yaml:"inMemory"`
+	// Connect is the retry of the connectivity check at startup
+	Connect Retry `yaml:"connect"`
+}
+
+// Retry makes Attempts tries, waiting Backoff after the first failure and
+// doubling the wait up to MaxBackoff.
+type Retry struct {
+	Attempts   int           `yaml:"attempts"`
+	Backoff    time.Duration `yaml:"backoff"`
+	MaxBackoff time.Duration `yaml:"maxBackoff"`
 }
 
 type Server struct {
+	Host           string                   `yaml:"host"`
+	Port           int                      `yaml:"port"`
+	RequestTimeout time.Duration            `yaml:"requestTimeout"`
+	RouteTimeouts  map[string]time.Duration `yaml:"routeTimeouts"`
+	// IdempotencyWindow is how long the responses of write requests are
+	// replayed for a repeated Idempotency-Key
+	IdempotencyWindow time.Duration `yaml:"idempotencyWindow"`
+	// Auth requires API keys on all routes but the OpenAPI document
+	Auth      bool      `yaml:"auth"`
+	RateLimit RateLimit `yaml:"rateLimit"`
+	TLS       TLS       `yaml:"tls"`
+}
+
+// TLS serves HTTPS when CertFile and KeyFile are set. With ClientCAFile the
+// client certificates signed by these CAs are verified, RequireClientCert
+// refuses the clients without one. Renewed files are loaded without restart.
+type TLS struct {
+	CertFile          string `yaml:"certFile"`
+	KeyFile           string `yaml:"keyFile"`
+	ClientCAFile      string `yaml:"clientCAFile"`
+	RequireClientCert bool   `yaml:"requireClientCert"`
+}
+
+// RateLimit limits the rotation and statistic requests of every API key and
+// of every client IP. A zero rate disables the limit.
+type RateLimit struct {
+	PerKey Limit `yaml:"perKey"`
+	PerIP  Limit `yaml:"perIP"`
+}
+
+type Limit struct {
+	// Rate is the number of requests per second
+	Rate float64 `yaml:"rate"`
+	// Burst is the number of requests allowed at once, a second worth of
+	// requests when zero
+	Burst int `yaml:"burst"`
+}
+
+type Rotation struct {
+	Strategy          string        `yaml:"strategy"`
+	Objective         string        `yaml:"objective"`
+	AttributionWindow time.Duration `yaml:"attributionWindow"`
+	// Slots override the strategy and the objective by slot ID
+	Slots map[int]SlotRotation `yaml:"slots"`
+}
+
+// SlotRotation is the selection of a slot, the empty fields are taken from
+// Rotation.
+type SlotRotation struct {
+	Strategy  string `yaml:"strategy"`
+	Objective string `yaml:"objective"`
+}
+
+// Fraud enables the filters of invalid clicks. The flagged clicks are stored
+// for audit and not counted.
+type Fraud struct {
+	Burst         ClickBurst    `yaml:"burst"`
+	MinClickDelay time.Duration `yaml:"minClickDelay"`
+	BotUserAgents []string      `yaml:"botUserAgents"`
+	// RequireImpression flags the clicks sent without the impression ID
+	// of the same banner and slot
+	RequireImpression bool `yaml:"requireImpression"`
+}
+
+// ClickBurst flags the clicks of an IP beyond Clicks per Window.
+type ClickBurst struct {
+	Clicks int           `yaml:"clicks"`
+	Window time.Duration `yaml:"window"`
+}
+
+// Segmentation resolves the social group from the request attributes. The
+// groups belong to a tenant, DefaultGroup and Rules are of the default tenant
+// and Tenants holds the ones of the other tenants.
+type Segmentation struct {
+	DefaultGroup int                           `yaml:"defaultGroup"`
+	Rules        []SegmentRule                 `yaml:"rules"`
+	Tenants      map[string]TenantSegmentation `yaml:"tenants"`
+}
+
+// TenantSegmentation is the segmentation of one tenant, the group IDs are of
+// that tenant.
+type TenantSegmentation struct {
+	DefaultGroup int           `yaml:"defaultGroup"`
+	Rules        []SegmentRule `yaml:"rules"`
+}
+
+type SegmentRule struct {
+	GroupID      int      `yaml:"groupId"`
+	AgeMin       int      `yaml:"ageMin"`
+	AgeMax       int      `yaml:"ageMax"`
+	Genders      []string `yaml:"genders"`
+	Devices      []string `yaml:"devices"`
+	Countries    []string `yaml:"countries"`
+	Referrers    []string `yaml:"referrers"`
+	UTMSources   []string `yaml:"utmSources"`
+	UTMMediums   []string `yaml:"utmMediums"`
+	UTMCampaigns []string `yaml:"utmCampaigns"`
+}
+
+type GRPCServer struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+	// Auth requires API keys on all calls
+	Auth      bool      `yaml:"auth"`
+	RateLimit RateLimit `yaml:"rateLimit"`
+}
+
+// Logger sets the minimal level (debug, info, warn or error) and the output
+// format (text or json) of the logs.
+type Logger struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// Tracing selects the span exporter: none, stdout for local runs or otlp to
+// send the spans to Endpoint over gRPC. SampleRatio is the share of the traces
+// started by the service, all of them when it is not set. The traces of the
+// callers are sampled as they are.
+type Tracing struct {
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	ServiceName string  `yaml:"serviceName"`
+	SampleRatio float64 `yaml:"sampleRatio"`
+}
+
+// Metrics is the listener of the Prometheus /metrics endpoint, kept apart
+// from the API. A zero port disables it.
+type Metrics struct {
 	Host string `yaml:"host"`
 	Port int    `yaml:"port"`
 }
@@ -36,18 +187,68 @@ type Broker struct {
 	RoutingKey   string `yaml:"routingKey"`
 }
 
-func GetBannersConfig(configFilePath string) Config {
-	conf := &Config{}
+// Default returns the values used for the fields missing from the config
+// file and the environment.
+func Default() Config {
+	return Config{
+		Database: DBConfig{
+			Host:    "localhost",
+			Port:    5432,
+			User:    "postgres",
+			DBName:  "banners_rotation",
+			Connect: Retry{Attempts: 5, Backoff: 500 * time.Millisecond, MaxBackoff: 10 * time.Second},
+		},
+		Server: Server{
+			Host:              "localhost",
+			Port:              8888,
+			Auth:              true,
+			RequestTimeout:    5 * time.Second,
+			IdempotencyWindow: 24 * time.Hour,
+		},
+		GRPC: GRPCServer{
+			Host: "localhost",
+			Port: 8889,
+			Auth: true,
+		},
+		Logger: Logger{
+			Level:  "info",
+			Format: "text",
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			ServiceName: "banners-rotation",
+			SampleRatio: 1,
+		},
+	}
+}
+
+// GetBannersConfig reads the config file over the defaults, applies the
+// environment overrides and validates the result. Without a file path the
+// config comes from the defaults and the environment only.
+func GetBannersConfig(configFilePath string) (Config, error) {
+	conf := Default()
 
-	file, err := os.Open(configFilePath)
-	if err != nil {
-		panic(err)
+	if configFilePath != "" {
+		file, err := os.Open(configFilePath)
+		if err != nil {
+			return Config{}, err
+		}
+		defer file.Close()
+
+		decoder := yaml.NewDecoder(file)
+		decoder.KnownFields(true)
+		if err = decoder.Decode(&conf); err != nil && !errors.Is(err, io.EOF) {
+			return Config{}, fmt.Errorf("%s: %w", configFilePath, err)
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(&conf).Elem(), EnvPrefix, os.LookupEnv); err != nil {
+		return Config{}, err
 	}
-	defer file.Close()
-	decoder := yaml.NewDecoder(file)
-	if err = decoder.Decode(conf); err != nil {
-		panic(err)
+
+	if err := conf.Validate(); err != nil {
+		return Config{}, err
 	}
 
-	return *conf
+	return conf, nil
 }
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the names of the environment variables that override the
+// config file. The name of a field is the path of its YAML keys in upper snake
+// case, so db.password is BANNERS_DB_PASSWORD and server.rateLimit.perIP.rate
+// is BANNERS_SERVER_RATE_LIMIT_PER_IP_RATE. Lists of strings are
+// comma-separated. Maps and lists of objects, like the segmentation rules, are
+// given in YAML flow style or JSON with the keys of the file, for example
+// BANNERS_SERVER_ROUTE_TIMEOUTS='{"POST /v1/events": 30s}'.
+const EnvPrefix = "BANNERS"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv sets the fields of the struct v from the variables found by lookup.
+func applyEnv(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+
+		name := prefix + "_" + envName(key)
+		value := v.Field(i)
+
+		if value.Kind() == reflect.Struct {
+			if err := applyEnv(value, name, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		env, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := setField(value, env); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func setField(value reflect.Value, env string) error {
+	if value.Type() == durationType {
+		d, err := time.ParseDuration(env)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", env)
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(env)
+	case reflect.Int:
+		n, err := strconv.Atoi(env)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", env)
+		}
+		value.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(env, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", env)
+		}
+		value.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", env)
+		}
+		value.SetBool(b)
+	case reflect.Slice:
+		if value.Type().Elem().Kind() != reflect.String {
+			return setYAML(value, env)
+		}
+		items := make([]string, 0)
+		for _, item := range strings.Split(env, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		return setYAML(value, env)
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+
+	return nil
+}
+
+// setYAML replaces the value with the one decoded from the YAML or JSON env.
+func setYAML(value reflect.Value, env string) error {
+	decoded := reflect.New(value.Type())
+
+	decoder := yaml.NewDecoder(strings.NewReader(env))
+	decoder.KnownFields(true)
+	// an empty variable clears the value
+	if err := decoder.Decode(decoded.Interface()); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid YAML %q: %w", env, err)
+	}
+
+	value.Set(decoded.Elem())
+	return nil
+}
+
+// envName converts a YAML key to upper snake case: rateLimit is RATE_LIMIT,
+// perIP is PER_IP.
+func envName(key string) string {
+	var b strings.Builder
+	runes := []rune(key)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && !unicode.IsUpper(runes[i-1]) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,291 @@
+package config
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "host", want: "HOST"},
+		{key: "dbName", want: "DB_NAME"},
+		{key: "rateLimit", want: "RATE_LIMIT"},
+		{key: "perIP", want: "PER_IP"},
+		{key: "clientCAFile", want: "CLIENT_CAFILE"},
+		{key: "requireClientCert", want: "REQUIRE_CLIENT_CERT"},
+	}
+
+	for _, tt := range tests {
+		if got := envName(tt.key); got != tt.want {
+			t.Errorf("envName(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(Config) bool
+		wantErr string
+	}{
+		{
+			name:  "nothing set",
+			check: func(c Config) bool { return reflect.DeepEqual(c, Default()) },
+		},
+		{
+			name:  "string",
+			env:   map[string]string{"BANNERS_DB_PASSWORD": "secret"},
+			check: func(c Config) bool { return c.Database.Password == "secret" },
+		},
+		{
+			name:  "integer",
+			env:   map[string]string{"BANNERS_DB_PORT": "6432"},
+			check: func(c Config) bool { return c.Database.Port == 6432 },
+		},
+		{
+			name:  "nested number",
+			env:   map[string]string{"BANNERS_SERVER_RATE_LIMIT_PER_IP_RATE": "2.5"},
+			check: func(c Config) bool { return c.Server.RateLimit.PerIP.Rate == 2.5 },
+		},
+		{
+			name:  "boolean",
+			env:   map[string]string{"BANNERS_GRPC_AUTH": "false"},
+			check: func(c Config) bool { return !c.GRPC.Auth },
+		},
+		{
+			name:  "duration",
+			env:   map[string]string{"BANNERS_DB_CONNECT_BACKOFF": "2s"},
+			check: func(c Config) bool { return c.Database.Connect.Backoff == 2*time.Second },
+		},
+		{
+			name: "list",
+			env:  map[string]string{"BANNERS_FRAUD_BOT_USER_AGENTS": "bot, curl,,"},
+			check: func(c Config) bool {
+				return slices.Equal(c.Fraud.BotUserAgents, []string{"bot", "curl"})
+			},
+		},
+		{
+			name:    "invalid integer",
+			env:     map[string]string{"BANNERS_DB_PORT": "db"},
+			wantErr: `BANNERS_DB_PORT: invalid integer "db"`,
+		},
+		{
+			name:    "invalid duration",
+			env:     map[string]string{"BANNERS_SERVER_REQUEST_TIMEOUT": "5"},
+			wantErr: `BANNERS_SERVER_REQUEST_TIMEOUT: invalid duration "5"`,
+		},
+		{
+			name:    "invalid boolean",
+			env:     map[string]string{"BANNERS_SERVER_AUTH": "maybe"},
+			wantErr: `BANNERS_SERVER_AUTH: invalid boolean "maybe"`,
+		},
+		{
+			name: "map",
+			env:  map[string]string{"BANNERS_SERVER_ROUTE_TIMEOUTS": `{"POST /v1/events": 30s, "GET /v1/slots": 1s}`},
+			check: func(c Config) bool {
+				return maps.Equal(c.Server.RouteTimeouts, map[string]time.Duration{
+					"POST /v1/events": 30 * time.Second,
+					"GET /v1/slots":   time.Second,
+				})
+			},
+		},
+		{
+			name: "map of objects",
+			env:  map[string]string{"BANNERS_ROTATION_SLOTS": `{7: {strategy: thompson}}`},
+			check: func(c Config) bool {
+				return maps.Equal(c.Rotation.Slots, map[int]SlotRotation{7: {Strategy: "thompson"}})
+			},
+		},
+		{
+			name: "list of objects",
+			env:  map[string]string{"BANNERS_SEGMENTATION_RULES": `[{groupId: 2, genders: [f]}, {groupId: 3, ageMin: 18}]`},
+			check: func(c Config) bool {
+				return reflect.DeepEqual(c.Segments.Rules, []SegmentRule{
+					{GroupID: 2, Genders: []string{"f"}},
+					{GroupID: 3, AgeMin: 18},
+				})
+			},
+		},
+		{
+			name:  "empty map",
+			env:   map[string]string{"BANNERS_ROTATION_SLOTS": ""},
+			check: func(c Config) bool { return c.Rotation.Slots == nil },
+		},
+		{
+			name:    "invalid map",
+			env:     map[string]string{"BANNERS_SERVER_ROUTE_TIMEOUTS": "events=1s"},
+			wantErr: "BANNERS_SERVER_ROUTE_TIMEOUTS: invalid YAML",
+		},
+		{
+			name:    "unknown key of an object",
+			env:     map[string]string{"BANNERS_SEGMENTATION_RULES": "[{group: 1}]"},
+			wantErr: "BANNERS_SEGMENTATION_RULES: invalid YAML",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := Default()
+			lookup := func(name string) (string, bool) {
+				value, ok := tt.env[name]
+				return value, ok
+			}
+
+			err := applyEnv(reflect.ValueOf(&conf).Elem(), EnvPrefix, lookup)
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Errorf("applyEnv() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("applyEnv() error = %v", err)
+			}
+			if !tt.check(conf) {
+				t.Errorf("applyEnv() = %+v", conf)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   []string
+	}{
+		{name: "defaults", modify: func(*Config) {}},
+		{
+			name: "database",
+			modify: func(c *Config) {
+				c.Database.Host = " "
+				c.Database.Port = 70000
+				c.Database.Connect.Attempts = -1
+			},
+			want: []string{
+				"db.host: is required",
+				"db.port: must be between 1 and 65535, got 70000",
+				"db.connect.attempts: must not be negative, got -1",
+			},
+		},
+		{
+			name: "shared port",
+			modify: func(c *Config) {
+				c.GRPC.Port = c.Server.Port
+				c.Metrics.Port = c.Server.Port
+			},
+			want: []string{
+				"grpc.port: is already used by server.port",
+				"metrics.port: is already used by grpc.port",
+			},
+		},
+		{
+			name: "rate limit",
+			modify: func(c *Config) {
+				c.Server.RateLimit.PerIP = Limit{Rate: 10, Burst: -1}
+				c.Server.RateLimit.PerKey = Limit{Rate: 10}
+				c.GRPC.RateLimit.PerKey = Limit{Rate: -1}
+			},
+			want: []string{
+				"server.rateLimit.perIP.burst: must not be negative, got -1",
+				"grpc.rateLimit.perKey.rate: must not be negative, got -1",
+			},
+		},
+		{
+			name: "tls",
+			modify: func(c *Config) {
+				c.Server.TLS = TLS{CertFile: "cert.pem", RequireClientCert: true}
+			},
+			want: []string{
+				"server.tls: certFile and keyFile must be set together",
+				"server.tls.requireClientCert: requires clientCAFile",
+			},
+		},
+		{
+			name: "rotation",
+			modify: func(c *Config) {
+				c.Rotation.Strategy = "greedy"
+				c.Rotation.Slots = map[int]SlotRotation{2: {Objective: "clicks"}, 0: {Strategy: "ucb1"}}
+			},
+			want: []string{
+				`rotation.strategy: must be one of ucb1, thompson, revenue, got "greedy"`,
+				"rotation.slots[0]: slot ID must be positive",
+				`rotation.slots[2].objective: must be one of ctr, cvr, value, got "clicks"`,
+			},
+		},
+		{
+			name: "segmentation",
+			modify: func(c *Config) {
+				c.Segments.Rules = []SegmentRule{{GroupID: 1, AgeMin: 30, AgeMax: 20}}
+				c.Segments.Tenants = map[string]TenantSegmentation{
+					"acme":    {Rules: []SegmentRule{{GroupID: 0}}},
+					"default": {Rules: []SegmentRule{{GroupID: 2}}},
+					"Acme":    {DefaultGroup: 3},
+				}
+			},
+			want: []string{
+				"segmentation.rules[0].ageMin: must not exceed ageMax",
+				"segmentation.tenants[Acme]: tenant must be lowercase letters, digits, _ or - up to 63 characters",
+				"segmentation.tenants[acme].rules[0].groupId: must be positive, got 0",
+				"segmentation.tenants[default]: the default tenant is segmented by segmentation.rules",
+			},
+		},
+		{
+			name: "fraud",
+			modify: func(c *Config) {
+				c.Fraud.Burst = ClickBurst{Clicks: 5}
+				c.Fraud.MinClickDelay = -time.Second
+			},
+			want: []string{
+				"fraud.burst.window: must be positive when clicks are set",
+				"fraud.minClickDelay: must not be negative, got -1s",
+			},
+		},
+		{
+			name: "logger and tracing",
+			modify: func(c *Config) {
+				c.Logger.Level = "trace"
+				c.Tracing.Exporter = "OTLP"
+				c.Tracing.SampleRatio = 2
+			},
+			want: []string{
+				`logger.level: must be one of debug, info, warn, error, got "trace"`,
+				"tracing.endpoint: is required",
+				"tracing.sampleRatio: must be between 0 and 1, got 2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := Default()
+			tt.modify(&conf)
+
+			err := conf.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+
+			problems, ok := err.(ValidationError)
+			if !ok {
+				t.Fatalf("Validate() = %v, want a ValidationError", err)
+			}
+			if !slices.Equal(problems, tt.want) {
+				t.Errorf("Validate() problems:\n  %s\nwant:\n  %s",
+					strings.Join(problems, "\n  "), strings.Join(tt.want, "\n  "))
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package config_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/banner"
+	"github.com/otus-murashko/banners-rotation/internal/config"
+)
+
+// The names accepted by Validate are repeated from the banner package, which
+// imports config.
+func TestRotationNamesMatchBanner(t *testing.T) {
+	for _, strategy := range banner.Strategies {
+		conf := config.Default()
+		conf.Rotation.Strategy = strategy
+		conf.Rotation.Slots = map[int]config.SlotRotation{1: {Strategy: strategy}}
+		if err := conf.Validate(); err != nil {
+			t.Errorf("strategy %q: %v", strategy, err)
+		}
+	}
+
+	for _, objective := range []string{"", "ctr", "cvr", "value", "CTR", "clicks"} {
+		_, parseErr := banner.ParseObjective(objective)
+
+		conf := config.Default()
+		conf.Rotation.Objective = objective
+		conf.Rotation.Slots = map[int]config.SlotRotation{1: {Objective: objective}}
+		err := conf.Validate()
+		if (err == nil) != (parseErr == nil) {
+			t.Errorf("objective %q: Validate() = %v, ParseObjective() = %v", objective, err, parseErr)
+		}
+	}
+
+	for _, strategy := range []string{"UCB1", "epsilon"} {
+		conf := config.Default()
+		conf.Rotation.Slots = map[int]config.SlotRotation{1: {Strategy: strategy}}
+		err := conf.Validate()
+		if err == nil || !strings.Contains(err.Error(), "rotation.slots[1].strategy") {
+			t.Errorf("strategy %q: Validate() = %v, want an error of rotation.slots[1].strategy", strategy, err)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/tenant"
+)
+
+// strategies and objectives are the names known to the banner package. The
+// banner package imports config, so the names are repeated here and a test
+// keeps them in sync.
+var (
+	strategies = []string{"ucb1", "thompson", "revenue"}
+	objectives = []string{"ctr", "cvr", "value"}
+)
+
+// ValidationError lists every problem found in the config, each prefixed
+// with the path of the field.
+type ValidationError []string
+
+func (e ValidationError) Error() string {
+	return "invalid config:\n  " + strings.Join(e, "\n  ")
+}
+
+type validator struct {
+	problems ValidationError
+}
+
+func (v *validator) add(field, format string, args ...any) {
+	v.problems = append(v.problems, field+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "is required")
+	}
+}
+
+func (v *validator) port(field string, port int, optional bool) {
+	if optional && port == 0 {
+		return
+	}
+	if port < 1 || port > 65535 {
+		v.add(field, "must be between 1 and 65535, got %d", port)
+	}
+}
+
+func (v *validator) notNegative(field string, d time.Duration) {
+	if d < 0 {
+		v.add(field, "must not be negative, got %s", d)
+	}
+}
+
+func (v *validator) oneOf(field, value string, allowed ...string) {
+	if value != "" && !slices.Contains(allowed, strings.ToLower(value)) {
+		v.add(field, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+	}
+}
+
+// name checks a name that is matched case-sensitively at runtime.
+func (v *validator) name(field, value string, allowed ...string) {
+	if value != "" && !slices.Contains(allowed, value) {
+		v.add(field, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+	}
+}
+
+func (v *validator) limit(field string, limit Limit) {
+	if limit.Rate < 0 {
+		v.add(field+".rate", "must not be negative, got %g", limit.Rate)
+	}
+	if limit.Burst < 0 {
+		v.add(field+".burst", "must not be negative, got %d", limit.Burst)
+	}
+}
+
+func (v *validator) segmentRules(field string, rules []SegmentRule) {
+	for i, rule := range rules {
+		ruleField := fmt.Sprintf("%s[%d]", field, i)
+		if rule.GroupID <= 0 {
+			v.add(ruleField+".groupId", "must be positive, got %d", rule.GroupID)
+		}
+		if rule.AgeMax != 0 && rule.AgeMin > rule.AgeMax {
+			v.add(ruleField+".ageMin", "must not exceed ageMax")
+		}
+	}
+}
+
+// Validate checks the values that would otherwise fail at runtime.
+func (c Config) Validate() error {
+	v := &validator{}
+
+	v.required("db.host", c.Database.Host)
+	v.port("db.port", c.Database.Port, false)
+	v.required("db.user", c.Database.User)
+	v.required("db.dbName", c.Database.DBName)
+	if c.Database.Connect.Attempts < 0 {
+		v.add("db.connect.attempts", "must not be negative, got %d", c.Database.Connect.Attempts)
+	}
+	v.notNegative("db.connect.backoff", c.Database.Connect.Backoff)
+	v.notNegative("db.connect.maxBackoff", c.Database.Connect.MaxBackoff)
+
+	v.port("server.port", c.Server.Port, false)
+	v.notNegative("server.requestTimeout", c.Server.RequestTimeout)
+	for route, timeout := range c.Server.RouteTimeouts {
+		v.notNegative(fmt.Sprintf("server.routeTimeouts[%s]", route), timeout)
+	}
+	v.notNegative("server.idempotencyWindow", c.Server.IdempotencyWindow)
+	v.limit("server.rateLimit.perKey", c.Server.RateLimit.PerKey)
+	v.limit("server.rateLimit.perIP", c.Server.RateLimit.PerIP)
+	if (c.Server.TLS.CertFile == "") != (c.Server.TLS.KeyFile == "") {
+		v.add("server.tls", "certFile and keyFile must be set together")
+	}
+	if c.Server.TLS.ClientCAFile != "" && c.Server.TLS.CertFile == "" {
+		v.add("server.tls.clientCAFile", "requires certFile and keyFile")
+	}
+	if c.Server.TLS.RequireClientCert && c.Server.TLS.ClientCAFile == "" {
+		v.add("server.tls.requireClientCert", "requires clientCAFile")
+	}
+
+	v.port("grpc.port", c.GRPC.Port, false)
+	v.limit("grpc.rateLimit.perKey", c.GRPC.RateLimit.PerKey)
+	v.limit("grpc.rateLimit.perIP", c.GRPC.RateLimit.PerIP)
+
+	v.port("metrics.port", c.Metrics.Port, true)
+
+	ports := map[int]string{}
+	for _, listener := range []struct {
+		field string
+		port  int
+	}{{"server.port", c.Server.Port}, {"grpc.port", c.GRPC.Port}, {"metrics.port", c.Metrics.Port}} {
+		if listener.port == 0 {
+			continue
+		}
+		if other, ok := ports[listener.port]; ok {
+			v.add(listener.field, "is already used by %s", other)
+		}
+		ports[listener.port] = listener.field
+	}
+
+	v.segmentRules("segmentation.rules", c.Segments.Rules)
+	tenantIDs := make([]string, 0, len(c.Segments.Tenants))
+	for tenantID := range c.Segments.Tenants {
+		tenantIDs = append(tenantIDs, tenantID)
+	}
+	slices.Sort(tenantIDs)
+	for _, tenantID := range tenantIDs {
+		field := fmt.Sprintf("segmentation.tenants[%s]", tenantID)
+		switch {
+		case !tenant.Valid(tenantID):
+			v.add(field, "tenant must be lowercase letters, digits, _ or - up to 63 characters")
+		case tenantID == tenant.Default:
+			v.add(field, "the default tenant is segmented by segmentation.rules")
+		}
+		v.segmentRules(field+".rules", c.Segments.Tenants[tenantID].Rules)
+	}
+
+	v.name("rotation.strategy", c.Rotation.Strategy, strategies...)
+	v.name("rotation.objective", c.Rotation.Objective, objectives...)
+	v.notNegative("rotation.attributionWindow", c.Rotation.AttributionWindow)
+	slotIDs := make([]int, 0, len(c.Rotation.Slots))
+	for slotID := range c.Rotation.Slots {
+		slotIDs = append(slotIDs, slotID)
+	}
+	slices.Sort(slotIDs)
+	for _, slotID := range slotIDs {
+		field := fmt.Sprintf("rotation.slots[%d]", slotID)
+		if slotID <= 0 {
+			v.add(field, "slot ID must be positive")
+		}
+		v.name(field+".strategy", c.Rotation.Slots[slotID].Strategy, strategies...)
+		v.name(field+".objective", c.Rotation.Slots[slotID].Objective, objectives...)
+	}
+
+	if c.Fraud.Burst.Clicks < 0 {
+		v.add("fraud.burst.clicks", "must not be negative, got %d", c.Fraud.Burst.Clicks)
+	}
+	if c.Fraud.Burst.Clicks > 0 && c.Fraud.Burst.Window <= 0 {
+		v.add("fraud.burst.window", "must be positive when clicks are set")
+	}
+	v.notNegative("fraud.minClickDelay", c.Fraud.MinClickDelay)
+
+	v.oneOf("logger.level", c.Logger.Level, "debug", "info", "warn", "error")
+	v.oneOf("logger.format", c.Logger.Format, "text", "json")
+
+	v.oneOf("tracing.exporter", c.Tracing.Exporter, "none", "stdout", "otlp")
+	if strings.ToLower(c.Tracing.Exporter) == "otlp" {
+		v.required("tracing.endpoint", c.Tracing.Endpoint)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		v.add("tracing.sampleRatio", "must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+	}
+
+	if len(v.problems) > 0 {
+		return v.problems
+	}
+	return nil
+}
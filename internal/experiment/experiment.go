@@ -0,0 +1,110 @@
+package experiment
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+// z-score of the two-sided 95% confidence level.
+const confidenceZ = 1.96
+
+type ArmReport struct {
+	Arm         string
+	Strategy    string
+	ShowsCount  int
+	ClicksCount int
+	CTR         float64
+	CILow       float64
+	CIHigh      float64
+}
+
+func Validate(exp storage.Experiment, strategies []string) error {
+	if len(exp.Arms) < 2 {
+		return apperror.Validation("experiment needs at least two arms, got %d", len(exp.Arms))
+	}
+
+	names := make(map[string]struct{}, len(exp.Arms))
+	for _, arm := range exp.Arms {
+		if arm.Name == "" {
+			return apperror.Validation("arm name is empty")
+		}
+		if _, ok := names[arm.Name]; ok {
+			return apperror.Validation("duplicate arm %q", arm.Name)
+		}
+		names[arm.Name] = struct{}{}
+
+		if arm.Weight <= 0 {
+			return apperror.Validation("arm %q: weight must be positive", arm.Name)
+		}
+		if !slices.Contains(strategies, arm.Strategy) {
+			return apperror.Validation("arm %q: unknown selection strategy %q", arm.Name, arm.Strategy)
+		}
+	}
+
+	return nil
+}
+
+// PickArm splits traffic between the arms proportionally to their weights.
+func PickArm(arms []storage.ExperimentArm) storage.ExperimentArm {
+	total := 0
+	for _, arm := range arms {
+		total += arm.Weight
+	}
+
+	if total <= 0 {
+		return arms[rand.IntN(len(arms))]
+	}
+
+	n := rand.IntN(total)
+	for _, arm := range arms {
+		if n < arm.Weight {
+			return arm
+		}
+		n -= arm.Weight
+	}
+
+	return arms[len(arms)-1]
+}
+
+// Report calculates CTR of every experiment arm with the Wilson score interval.
+func Report(exp storage.Experiment, stats []storage.ArmStatistic) []ArmReport {
+	byArm := make(map[string]storage.ArmStatistic, len(stats))
+	for _, stat := range stats {
+		byArm[stat.Arm] = stat
+	}
+
+	reports := make([]ArmReport, 0, len(exp.Arms))
+	for _, arm := range exp.Arms {
+		stat := byArm[arm.Name]
+		report := ArmReport{
+			Arm:         arm.Name,
+			Strategy:    arm.Strategy,
+			ShowsCount:  stat.ShowsCount,
+			ClicksCount: stat.ClicksCount,
+		}
+
+		if stat.ShowsCount > 0 {
+			report.CTR = float64(stat.ClicksCount) / float64(stat.ShowsCount)
+			report.CILow, report.CIHigh = wilsonInterval(stat.ClicksCount, stat.ShowsCount)
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports
+}
+
+func wilsonInterval(clicks, shows int) (float64, float64) {
+	n := float64(shows)
+	p := float64(clicks) / n
+	z2 := confidenceZ * confidenceZ
+
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	margin := confidenceZ * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / (1 + z2/n)
+
+	return math.Max(0, center-margin), math.Min(1, center+margin)
+}
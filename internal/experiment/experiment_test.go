@@ -0,0 +1,141 @@
+package experiment
+
+import (
+	"math"
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+const epsilon = 1e-4
+
+func TestWilsonInterval(t *testing.T) {
+	tests := []struct {
+		name              string
+		clicks, shows     int
+		wantLow, wantHigh float64
+	}{
+		{name: "no clicks", clicks: 0, shows: 10, wantLow: 0, wantHigh: 0.2775},
+		{name: "half", clicks: 50, shows: 100, wantLow: 0.4038, wantHigh: 0.5962},
+		{name: "all clicked", clicks: 10, shows: 10, wantLow: 0.7225, wantHigh: 1},
+		{name: "rare clicks", clicks: 1, shows: 1000, wantLow: 0.0002, wantHigh: 0.0056},
+		{name: "few shows", clicks: 3, shows: 20, wantLow: 0.0524, wantHigh: 0.3604},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			low, high := wilsonInterval(tt.clicks, tt.shows)
+			if math.Abs(low-tt.wantLow) > epsilon || math.Abs(high-tt.wantHigh) > epsilon {
+				t.Errorf("wilsonInterval(%d, %d) = (%.4f, %.4f), want (%.4f, %.4f)",
+					tt.clicks, tt.shows, low, high, tt.wantLow, tt.wantHigh)
+			}
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	exp := storage.Experiment{
+		ID: 1,
+		Arms: []storage.ExperimentArm{
+			{Name: "a", Strategy: "ucb1", Weight: 1},
+			{Name: "b", Strategy: "thompson", Weight: 1},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		stats []storage.ArmStatistic
+		want  []ArmReport
+	}{
+		{
+			name: "no statistic",
+			want: []ArmReport{
+				{Arm: "a", Strategy: "ucb1"},
+				{Arm: "b", Strategy: "thompson"},
+			},
+		},
+		{
+			name: "both arms",
+			stats: []storage.ArmStatistic{
+				{ExperimentID: 1, Arm: "b", ShowsCount: 10, ClicksCount: 10},
+				{ExperimentID: 1, Arm: "a", ShowsCount: 100, ClicksCount: 50},
+			},
+			want: []ArmReport{
+				{Arm: "a", Strategy: "ucb1", ShowsCount: 100, ClicksCount: 50, CTR: 0.5, CILow: 0.4038, CIHigh: 0.5962},
+				{Arm: "b", Strategy: "thompson", ShowsCount: 10, ClicksCount: 10, CTR: 1, CILow: 0.7225, CIHigh: 1},
+			},
+		},
+		{
+			name: "statistic of a removed arm",
+			stats: []storage.ArmStatistic{
+				{ExperimentID: 1, Arm: "c", ShowsCount: 10, ClicksCount: 1},
+			},
+			want: []ArmReport{
+				{Arm: "a", Strategy: "ucb1"},
+				{Arm: "b", Strategy: "thompson"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Report(exp, tt.stats)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Report() returned %d arms, want %d", len(got), len(tt.want))
+			}
+
+			for i, want := range tt.want {
+				g := got[i]
+				if g.Arm != want.Arm || g.Strategy != want.Strategy ||
+					g.ShowsCount != want.ShowsCount || g.ClicksCount != want.ClicksCount ||
+					math.Abs(g.CTR-want.CTR) > epsilon ||
+					math.Abs(g.CILow-want.CILow) > epsilon || math.Abs(g.CIHigh-want.CIHigh) > epsilon {
+					t.Errorf("Report()[%d] = %+v, want %+v", i, g, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	strategies := []string{"ucb1", "thompson"}
+	arm := func(name, strategy string, weight int) storage.ExperimentArm {
+		return storage.ExperimentArm{Name: name, Strategy: strategy, Weight: weight}
+	}
+
+	tests := []struct {
+		name    string
+		arms    []storage.ExperimentArm
+		wantErr bool
+	}{
+		{name: "valid", arms: []storage.ExperimentArm{arm("a", "ucb1", 1), arm("b", "thompson", 3)}},
+		{name: "one arm", arms: []storage.ExperimentArm{arm("a", "ucb1", 1)}, wantErr: true},
+		{name: "empty name", arms: []storage.ExperimentArm{arm("a", "ucb1", 1), arm("", "ucb1", 1)}, wantErr: true},
+		{name: "duplicate name", arms: []storage.ExperimentArm{arm("a", "ucb1", 1), arm("a", "thompson", 1)}, wantErr: true},
+		{name: "zero weight", arms: []storage.ExperimentArm{arm("a", "ucb1", 1), arm("b", "ucb1", 0)}, wantErr: true},
+		{name: "unknown strategy", arms: []storage.ExperimentArm{arm("a", "ucb1", 1), arm("b", "random", 1)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(storage.Experiment{Arms: tt.arms}, strategies)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPickArmSkipsZeroWeights(t *testing.T) {
+	arms := []storage.ExperimentArm{
+		{Name: "a", Weight: 0},
+		{Name: "b", Weight: 5},
+		{Name: "c", Weight: 0},
+	}
+
+	for range 100 {
+		if got := PickArm(arms); got.Name != "b" {
+			t.Fatalf("PickArm() = %q, want the only weighted arm", got.Name)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package fraud
+
+import (
+	"strings"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/config"
+	"github.com/otus-murashko/banners-rotation/internal/ratelimit"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+// Reasons a click is flagged with.
+const (
+	ReasonIPBurst      = "ip_burst"
+	ReasonFastClick    = "fast_click"
+	ReasonBotUserAgent = "bot_user_agent"
+	ReasonNoImpression = "no_impression"
+)
+
+// Click is a click with what is known about its client and impression.
+// Impression is nil when the click has none or it is not found.
+type Click struct {
+	Stat       storage.Statistic
+	Client     storage.ClientInfo
+	At         time.Time
+	Impression *storage.Impression
+	// Relayed is set for the clicks reported in bulk, their client is known
+	// only when the reporter sends it
+	Relayed bool
+}
+
+// Filter flags a suspicious click with the reason.
+type Filter interface {
+	Check(click Click) (reason string, suspicious bool)
+}
+
+// Chain runs every filter, so the audit log keeps all the reasons of a click.
+type Chain []Filter
+
+func (c Chain) Check(click Click) []string {
+	reasons := make([]string, 0)
+	for _, filter := range c {
+		if reason, suspicious := filter.Check(click); suspicious {
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
+
+// NewChain builds the filters enabled in the configuration.
+func NewChain(conf config.Fraud) Chain {
+	chain := make(Chain, 0)
+
+	if conf.Burst.Clicks > 0 && conf.Burst.Window > 0 {
+		chain = append(chain, NewBurstFilter(conf.Burst.Clicks, conf.Burst.Window))
+	}
+	if conf.MinClickDelay > 0 {
+		chain = append(chain, MinDelayFilter{MinDelay: conf.MinClickDelay})
+	}
+	if len(conf.BotUserAgents) > 0 {
+		chain = append(chain, NewBotFilter(conf.BotUserAgents))
+	}
+	if conf.RequireImpression {
+		chain = append(chain, ImpressionFilter{})
+	}
+
+	return chain
+}
+
+// BurstFilter flags the clicks of an IP beyond clicks per window. The clicks
+// are counted at the time they were made. Clicks of an unknown IP are not
+// counted.
+type BurstFilter struct {
+	limiter *ratelimit.Limiter
+}
+
+func NewBurstFilter(clicks int, window time.Duration) BurstFilter {
+	return BurstFilter{limiter: ratelimit.New(float64(clicks)/window.Seconds(), clicks)}
+}
+
+func (f BurstFilter) Check(click Click) (string, bool) {
+	if click.Client.IP == "" {
+		return ReasonIPBurst, false
+	}
+
+	ok, _ := f.limiter.AllowAt(click.Client.IP, click.At)
+	return ReasonIPBurst, !ok
+}
+
+// MinDelayFilter flags the clicks that come sooner after the show than a
+// person can react.
+type MinDelayFilter struct {
+	MinDelay time.Duration
+}
+
+func (f MinDelayFilter) Check(click Click) (string, bool) {
+	if click.Impression == nil {
+		return ReasonFastClick, false
+	}
+	return ReasonFastClick, click.At.Sub(click.Impression.ShownAt) < f.MinDelay
+}
+
+// BotFilter flags the clicks of the user agents containing any of the
+// patterns, and of the clients sending no user agent at all. A relayed click
+// without a user agent is not flagged, the reporter may not know it.
+type BotFilter struct {
+	patterns []string
+}
+
+func NewBotFilter(patterns []string) BotFilter {
+	lower := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		lower = append(lower, strings.ToLower(pattern))
+	}
+	return BotFilter{patterns: lower}
+}
+
+func (f BotFilter) Check(click Click) (string, bool) {
+	userAgent := strings.ToLower(click.Client.UserAgent)
+	if userAgent == "" {
+		return ReasonBotUserAgent, !click.Relayed
+	}
+
+	for _, pattern := range f.patterns {
+		if strings.Contains(userAgent, pattern) {
+			return ReasonBotUserAgent, true
+		}
+	}
+	return ReasonBotUserAgent, false
+}
+
+// ImpressionFilter flags the clicks without an impression of the same
+// banner and slot.
+type ImpressionFilter struct{}
+
+func (ImpressionFilter) Check(click Click) (string, bool) {
+	imp := click.Impression
+	matches := imp != nil && imp.BannerID == click.Stat.BannerID && imp.SlotID == click.Stat.SlotID
+	return ReasonNoImpression, !matches
+}
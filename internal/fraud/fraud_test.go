@@ -0,0 +1,235 @@
+package fraud
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/config"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+var shownAt = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func click(ip, userAgent string, delay time.Duration, imp *storage.Impression) Click {
+	return Click{
+		Stat:       storage.Statistic{BannerID: 1, SlotID: 2},
+		Client:     storage.ClientInfo{IP: ip, UserAgent: userAgent},
+		At:         shownAt.Add(delay),
+		Impression: imp,
+	}
+}
+
+func relayed(c Click) Click {
+	c.Relayed = true
+	return c
+}
+
+func impression(bannerID, slotID int) *storage.Impression {
+	return &storage.Impression{ID: 7, BannerID: bannerID, SlotID: slotID, ShownAt: shownAt}
+}
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		name           string
+		filter         Filter
+		click          Click
+		wantReason     string
+		wantSuspicious bool
+	}{
+		{
+			name:       "slow click",
+			filter:     MinDelayFilter{MinDelay: time.Second},
+			click:      click("10.0.0.1", "Mozilla", 2*time.Second, impression(1, 2)),
+			wantReason: ReasonFastClick,
+		},
+		{
+			name:           "fast click",
+			filter:         MinDelayFilter{MinDelay: time.Second},
+			click:          click("10.0.0.1", "Mozilla", 500*time.Millisecond, impression(1, 2)),
+			wantReason:     ReasonFastClick,
+			wantSuspicious: true,
+		},
+		{
+			name:       "delay without impression",
+			filter:     MinDelayFilter{MinDelay: time.Second},
+			click:      click("10.0.0.1", "Mozilla", 0, nil),
+			wantReason: ReasonFastClick,
+		},
+		{
+			name:       "browser",
+			filter:     NewBotFilter([]string{"bot", "curl"}),
+			click:      click("10.0.0.1", "Mozilla/5.0", time.Second, nil),
+			wantReason: ReasonBotUserAgent,
+		},
+		{
+			name:           "bot of any case",
+			filter:         NewBotFilter([]string{"GoogleBot"}),
+			click:          click("10.0.0.1", "Mozilla/5.0 (compatible; googlebot/2.1)", time.Second, nil),
+			wantReason:     ReasonBotUserAgent,
+			wantSuspicious: true,
+		},
+		{
+			name:           "no user agent",
+			filter:         NewBotFilter(nil),
+			click:          click("10.0.0.1", "", time.Second, nil),
+			wantReason:     ReasonBotUserAgent,
+			wantSuspicious: true,
+		},
+		{
+			name:       "relayed click without user agent",
+			filter:     NewBotFilter([]string{"bot"}),
+			click:      relayed(click("10.0.0.1", "", time.Second, nil)),
+			wantReason: ReasonBotUserAgent,
+		},
+		{
+			name:           "relayed bot",
+			filter:         NewBotFilter([]string{"bot"}),
+			click:          relayed(click("10.0.0.1", "bot/1.0", time.Second, nil)),
+			wantReason:     ReasonBotUserAgent,
+			wantSuspicious: true,
+		},
+		{
+			name:       "impression of the click",
+			filter:     ImpressionFilter{},
+			click:      click("10.0.0.1", "Mozilla", time.Second, impression(1, 2)),
+			wantReason: ReasonNoImpression,
+		},
+		{
+			name:           "no impression",
+			filter:         ImpressionFilter{},
+			click:          click("10.0.0.1", "Mozilla", time.Second, nil),
+			wantReason:     ReasonNoImpression,
+			wantSuspicious: true,
+		},
+		{
+			name:           "impression of another banner",
+			filter:         ImpressionFilter{},
+			click:          click("10.0.0.1", "Mozilla", time.Second, impression(3, 2)),
+			wantReason:     ReasonNoImpression,
+			wantSuspicious: true,
+		},
+		{
+			name:           "impression of another slot",
+			filter:         ImpressionFilter{},
+			click:          click("10.0.0.1", "Mozilla", time.Second, impression(1, 3)),
+			wantReason:     ReasonNoImpression,
+			wantSuspicious: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, suspicious := tt.filter.Check(tt.click)
+			if reason != tt.wantReason || suspicious != tt.wantSuspicious {
+				t.Errorf("Check() = %q, %v, want %q, %v", reason, suspicious, tt.wantReason, tt.wantSuspicious)
+			}
+		})
+	}
+}
+
+func TestBurstFilter(t *testing.T) {
+	tests := []struct {
+		name           string
+		ip             string
+		delay          time.Duration
+		wantSuspicious bool
+	}{
+		{name: "first click", ip: "10.0.0.1"},
+		{name: "second click", ip: "10.0.0.1"},
+		{name: "click over the limit", ip: "10.0.0.1", wantSuspicious: true},
+		{name: "click of another IP", ip: "10.0.0.2"},
+		{name: "click made a window later", ip: "10.0.0.1", delay: time.Hour},
+		{name: "click of an unknown IP", ip: ""},
+		{name: "another click of an unknown IP", ip: ""},
+		{name: "third click of an unknown IP", ip: ""},
+	}
+
+	filter := NewBurstFilter(2, time.Hour)
+	for _, tt := range tests {
+		reason, suspicious := filter.Check(click(tt.ip, "Mozilla", tt.delay, nil))
+		if reason != ReasonIPBurst || suspicious != tt.wantSuspicious {
+			t.Errorf("%s: Check() = %q, %v, want %q, %v",
+				tt.name, reason, suspicious, ReasonIPBurst, tt.wantSuspicious)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	chain := Chain{
+		MinDelayFilter{MinDelay: time.Second},
+		NewBotFilter([]string{"bot"}),
+		ImpressionFilter{},
+	}
+
+	tests := []struct {
+		name  string
+		click Click
+		want  []string
+	}{
+		{name: "clean", click: click("10.0.0.1", "Mozilla", 2*time.Second, impression(1, 2)), want: []string{}},
+		{
+			name:  "every reason",
+			click: click("10.0.0.1", "bot", 0, impression(1, 3)),
+			want:  []string{ReasonFastClick, ReasonBotUserAgent, ReasonNoImpression},
+		},
+		{
+			name:  "some reasons",
+			click: click("10.0.0.1", "", 2*time.Second, nil),
+			want:  []string{ReasonBotUserAgent, ReasonNoImpression},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chain.Check(tt.click); !slices.Equal(got, tt.want) {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewChain(t *testing.T) {
+	tests := []struct {
+		name string
+		conf config.Fraud
+		want []string
+	}{
+		{name: "disabled", want: []string{}},
+		{
+			name: "burst without window",
+			conf: config.Fraud{Burst: config.ClickBurst{Clicks: 10}},
+			want: []string{},
+		},
+		{
+			name: "every filter",
+			conf: config.Fraud{
+				Burst:             config.ClickBurst{Clicks: 10, Window: time.Minute},
+				MinClickDelay:     time.Second,
+				BotUserAgents:     []string{"bot"},
+				RequireImpression: true,
+			},
+			want: []string{ReasonIPBurst, ReasonFastClick, ReasonBotUserAgent, ReasonNoImpression},
+		},
+		{
+			name: "bot filter only",
+			conf: config.Fraud{BotUserAgents: []string{"bot"}},
+			want: []string{ReasonBotUserAgent},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := NewChain(tt.conf)
+
+			got := make([]string, 0, len(chain))
+			for _, filter := range chain {
+				reason, _ := filter.Check(Click{})
+				got = append(got, reason)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("NewChain() filters = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/config"
+)
+
+func TestNew(t *testing.T) {
+	t.Cleanup(func() { SetLevel("") })
+
+	var buf bytes.Buffer
+	log, err := New(config.Logger{Level: "info", Format: "json"}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	log.DebugContext(ctx, "skipped")
+	log.InfoContext(ctx, "request", "status", 200)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("records = %q, want one record", lines)
+	}
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["msg"] != "request" || record["request_id"] != "req-1" || record["status"] != float64(200) {
+		t.Errorf("record = %v, want msg request, request_id req-1 and status 200", record)
+	}
+
+	buf.Reset()
+	log.With("component", "http").Info("started")
+	if got := buf.String(); strings.Contains(got, "request_id") || !strings.Contains(got, `"component":"http"`) {
+		t.Errorf("record without a context = %s", got)
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	t.Cleanup(func() { SetLevel("") })
+
+	tests := []struct {
+		name    string
+		conf    config.Logger
+		wantErr string
+	}{
+		{name: "unknown format", conf: config.Logger{Format: "xml"}, wantErr: `unknown log format "xml"`},
+		{name: "unknown level", conf: config.Logger{Level: "verbose"}, wantErr: `unknown log level "verbose"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.conf, &bytes.Buffer{})
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{id: "3f2c9a1e-7b4d-4e6a-9c1f-0d2b8e5a7c3d", want: true},
+		{id: NewRequestID(), want: true},
+		{id: "", want: false},
+		{id: "with space", want: false},
+		{id: "line\nbreak", want: false},
+		{id: "нелатиница", want: false},
+		{id: strings.Repeat("a", maxRequestIDLen), want: true},
+		{id: strings.Repeat("a", maxRequestIDLen+1), want: false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidRequestID(tt.id); got != tt.want {
+			t.Errorf("ValidRequestID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
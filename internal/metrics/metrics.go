@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "banners_rotation"
+
+// Registry holds the metrics of the service and of the Go runtime.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route and status.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	grpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "gRPC calls by method and code.",
+	}, []string{"method", "code"})
+
+	grpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "gRPC call latency by method and code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	selectionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "selection_duration_seconds",
+		Help:      "Banner selection latency by strategy, including the storage reads.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"strategy"})
+
+	storageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "storage_query_duration_seconds",
+		Help:      "Storage operation latency.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation"})
+
+	storageErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "storage_errors_total",
+		Help:      "Failed storage operations by error kind.",
+	}, []string{"operation", "kind"})
+
+	shows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "shows_total",
+		Help:      "Counted banner shows by slot.",
+	}, []string{"slot"})
+
+	clicks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "clicks_total",
+		Help:      "Counted banner clicks by slot.",
+	}, []string{"slot"})
+
+	invalidClicks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "invalid_clicks_total",
+		Help:      "Clicks flagged by the fraud filters by reason.",
+	}, []string{"reason"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		grpcRequests, grpcDuration,
+		selectionDuration,
+		storageDuration, storageErrors,
+		shows, clicks, invalidClicks,
+	)
+}
+
+// ObserveHTTP records a request of the route, the mux pattern it matched.
+func ObserveHTTP(route, method string, status int, elapsed time.Duration) {
+	labels := prometheus.Labels{"route": route, "method": method, "status": strconv.Itoa(status)}
+	httpRequests.With(labels).Inc()
+	httpDuration.With(labels).Observe(elapsed.Seconds())
+}
+
+func ObserveGRPC(method, code string, elapsed time.Duration) {
+	grpcRequests.WithLabelValues(method, code).Inc()
+	grpcDuration.WithLabelValues(method, code).Observe(elapsed.Seconds())
+}
+
+func ObserveSelection(strategy string, elapsed time.Duration) {
+	selectionDuration.WithLabelValues(strategy).Observe(elapsed.Seconds())
+}
+
+// ObserveStorage records a storage operation. Not found errors are expected
+// and not counted as failures.
+func ObserveStorage(operation string, elapsed time.Duration, err error) {
+	storageDuration.WithLabelValues(operation).Observe(elapsed.Seconds())
+
+	if err != nil && apperror.KindOf(err) != apperror.KindNotFound {
+		storageErrors.WithLabelValues(operation, string(apperror.KindOf(err))).Inc()
+	}
+}
+
+func CountShows(slotID, count int) {
+	shows.WithLabelValues(strconv.Itoa(slotID)).Add(float64(count))
+}
+
+func CountClicks(slotID, count int) {
+	clicks.WithLabelValues(strconv.Itoa(slotID)).Add(float64(count))
+}
+
+func CountInvalidClick(reasons []string) {
+	for _, reason := range reasons {
+		invalidClicks.WithLabelValues(reason).Inc()
+	}
+}
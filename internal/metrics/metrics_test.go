@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/config"
+)
+
+// sampleCount returns the value of the counter, or the sample count of the
+// histogram, of the family name with the labels.
+func sampleCount(t *testing.T, name string, labels map[string]string) uint64 {
+	t.Helper()
+
+	families, err := Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if labels[label.GetName()] != label.GetValue() {
+					continue metrics
+				}
+			}
+			if m.GetHistogram() != nil {
+				return m.GetHistogram().GetSampleCount()
+			}
+			return uint64(m.GetCounter().GetValue())
+		}
+	}
+	return 0
+}
+
+func TestObserveHTTP(t *testing.T) {
+	labels := map[string]string{"route": "GET /v1/slots/{id}/banner", "method": http.MethodGet, "status": "200"}
+	requests := sampleCount(t, "banners_rotation_http_requests_total", labels)
+	observed := sampleCount(t, "banners_rotation_http_request_duration_seconds", labels)
+
+	ObserveHTTP("GET /v1/slots/{id}/banner", http.MethodGet, http.StatusOK, time.Millisecond)
+	ObserveHTTP("GET /v1/slots/{id}/banner", http.MethodGet, http.StatusOK, time.Millisecond)
+
+	if got := sampleCount(t, "banners_rotation_http_requests_total", labels) - requests; got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+	if got := sampleCount(t, "banners_rotation_http_request_duration_seconds", labels) - observed; got != 2 {
+		t.Errorf("observed durations = %d, want 2", got)
+	}
+}
+
+func TestCountInvalidClick(t *testing.T) {
+	reasons := []string{"duplicate", "bot"}
+	before := make(map[string]uint64)
+	for _, reason := range reasons {
+		before[reason] = sampleCount(t, "banners_rotation_invalid_clicks_total", map[string]string{"reason": reason})
+	}
+
+	CountInvalidClick(reasons)
+
+	for _, reason := range reasons {
+		got := sampleCount(t, "banners_rotation_invalid_clicks_total", map[string]string{"reason": reason}) - before[reason]
+		if got != 1 {
+			t.Errorf("invalid clicks of %s = %d, want 1", reason, got)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	if s := NewServer(config.Metrics{}); s != nil {
+		t.Fatal("server without a port is not nil")
+	}
+
+	s := NewServer(config.Metrics{Port: 9090})
+	CountShows(1, 3)
+
+	w := httptest.NewRecorder()
+	s.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	body, _ := io.ReadAll(w.Body)
+	for _, name := range []string{"banners_rotation_shows_total", "go_goroutines", "process_start_time_seconds"} {
+		if !strings.Contains(string(body), name) {
+			t.Errorf("metrics do not contain %s", name)
+		}
+	}
+}
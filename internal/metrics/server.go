@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/config"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Server serves /metrics apart from the API, the metrics are of all the
+// tenants and must not be reachable with their API keys.
+type Server struct {
+	server *http.Server
+}
+
+// NewServer returns nil when no port is configured.
+func NewServer(conf config.Metrics) *Server {
+	if conf.Port == 0 {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+
+	return &Server{server: &http.Server{
+		Addr:              net.JoinHostPort(conf.Host, fmt.Sprint(conf.Port)),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}}
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+
+	s.server.BaseContext = func(net.Listener) context.Context { return ctx }
+
+	if err := s.server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	return s.server.Shutdown(ctx)
+}
@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the buckets of idle clients are dropped.
+const sweepInterval = time.Minute
+
+// Limiter is a token bucket per key. Every key may spend burst requests at
+// once and then rate requests per second. The buckets live in the process, so
+// every replica limits on its own.
+type Limiter struct {
+	rate  float64
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New returns a limiter of rate requests per second. A burst below one
+// allows a second worth of requests at once. A limiter of a rate that is not
+// positive allows everything, as a nil limiter does.
+func New(rate float64, burst int) *Limiter {
+	l := &Limiter{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+	l.rate, l.burst = limits(rate, burst)
+
+	return l
+}
+
+// SetLimit changes the rate and the burst of the running limiter. The
+// clients keep the tokens they have up to the new burst.
+func (l *Limiter) SetLimit(rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rate, l.burst = limits(rate, burst)
+	if l.rate <= 0 {
+		clear(l.buckets)
+		return
+	}
+
+	for _, b := range l.buckets {
+		b.tokens = math.Min(b.tokens, l.burst)
+	}
+}
+
+func limits(rate float64, burst int) (float64, float64) {
+	if rate <= 0 {
+		return 0, 0
+	}
+
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	return rate, float64(burst)
+}
+
+// Allow takes a token of the key. When the bucket is empty it reports false
+// with the time until the next token.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	return l.AllowAt(key, l.now())
+}
+
+// AllowAt takes a token of the key for a request made at the time, which may
+// be in the past for the requests reported later. A request older than the
+// last one of the key does not refill the bucket.
+func (l *Limiter) AllowAt(key string, now time.Time) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return true, 0
+	}
+
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	if now.After(b.last) {
+		b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// sweep drops the buckets that have refilled, they are the same as new ones.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// clock is a time source the tests move by hand.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLimiter(rate float64, burst int) (*Limiter, *clock) {
+	c := &clock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := New(rate, burst)
+	l.now = c.now
+	l.lastSweep = c.t
+	return l, c
+}
+
+func TestLimiterAllow(t *testing.T) {
+	type step struct {
+		advance   time.Duration
+		key       string
+		wantOK    bool
+		wantRetry time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		rate  float64
+		burst int
+		steps []step
+	}{
+		{
+			name: "burst then empty", rate: 1, burst: 2,
+			steps: []step{
+				{key: "a", wantOK: true},
+				{key: "a", wantOK: true},
+				{key: "a", wantRetry: time.Second},
+			},
+		},
+		{
+			name: "refill over time", rate: 2, burst: 1,
+			steps: []step{
+				{key: "a", wantOK: true},
+				{key: "a", wantRetry: 500 * time.Millisecond},
+				{advance: 250 * time.Millisecond, key: "a", wantRetry: 250 * time.Millisecond},
+				{advance: 250 * time.Millisecond, key: "a", wantOK: true},
+			},
+		},
+		{
+			name: "refill is capped at burst", rate: 1, burst: 1,
+			steps: []step{
+				{key: "a", wantOK: true},
+				{advance: time.Hour, key: "a", wantOK: true},
+				{key: "a", wantRetry: time.Second},
+			},
+		},
+		{
+			name: "keys have own buckets", rate: 1, burst: 1,
+			steps: []step{
+				{key: "a", wantOK: true},
+				{key: "a", wantRetry: time.Second},
+				{key: "b", wantOK: true},
+			},
+		},
+		{
+			name: "default burst is a second of requests", rate: 2.5,
+			steps: []step{
+				{key: "a", wantOK: true},
+				{key: "a", wantOK: true},
+				{key: "a", wantOK: true},
+				{key: "a", wantRetry: 400 * time.Millisecond},
+			},
+		},
+		{
+			name: "rate that is not positive allows everything",
+			steps: []step{
+				{key: "a", wantOK: true},
+				{key: "a", wantOK: true},
+				{key: "a", wantOK: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, c := newTestLimiter(tt.rate, tt.burst)
+
+			for i, s := range tt.steps {
+				c.advance(s.advance)
+				ok, retry := l.Allow(s.key)
+				if ok != s.wantOK || retry != s.wantRetry {
+					t.Errorf("step %d: Allow(%q) = %v, %s, want %v, %s", i, s.key, ok, retry, s.wantOK, s.wantRetry)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterAllowAt(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		at     []time.Duration
+		wantOK []bool
+	}{
+		{
+			name:   "requests spread over time",
+			at:     []time.Duration{0, time.Second, 2 * time.Second, 3 * time.Second},
+			wantOK: []bool{true, true, true, true},
+		},
+		{
+			name:   "requests at once",
+			at:     []time.Duration{0, 0, 0},
+			wantOK: []bool{true, false, false},
+		},
+		{
+			name:   "older request does not refill",
+			at:     []time.Duration{time.Hour, 0, time.Hour},
+			wantOK: []bool{true, false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := newTestLimiter(1, 1)
+
+			for i, at := range tt.at {
+				if ok, _ := l.AllowAt("a", start.Add(at)); ok != tt.wantOK[i] {
+					t.Errorf("request %d at %s: AllowAt() = %v, want %v", i, at, ok, tt.wantOK[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNilLimiter(t *testing.T) {
+	var l *Limiter
+	if ok, retry := l.Allow("a"); !ok || retry != 0 {
+		t.Errorf("Allow() of a nil limiter = %v, %s, want true, 0", ok, retry)
+	}
+	if ok, retry := l.AllowAt("a", time.Now()); !ok || retry != 0 {
+		t.Errorf("AllowAt() of a nil limiter = %v, %s, want true, 0", ok, retry)
+	}
+}
+
+func TestLimiterSetLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		rate   float64
+		burst  int
+		wantOK []bool
+	}{
+		{name: "lower burst keeps fewer tokens", rate: 1, burst: 1, wantOK: []bool{true, false}},
+		{name: "higher burst does not add tokens", rate: 1, burst: 10, wantOK: []bool{true, true, false}},
+		{name: "disabled", rate: 0, wantOK: []bool{true, true, true, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := newTestLimiter(1, 3)
+			l.Allow("a")
+
+			l.SetLimit(tt.rate, tt.burst)
+			for i, want := range tt.wantOK {
+				if ok, _ := l.Allow("a"); ok != want {
+					t.Errorf("request %d: Allow() = %v, want %v", i, ok, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterSweep(t *testing.T) {
+	l, c := newTestLimiter(1, 2)
+	l.Allow("idle")
+	c.advance(sweepInterval - time.Second)
+	l.Allow("busy")
+
+	c.advance(time.Second)
+	l.Allow("other")
+
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("bucket of an idle key was not swept")
+	}
+	if _, ok := l.buckets["busy"]; !ok {
+		t.Error("bucket of a busy key was swept")
+	}
+}
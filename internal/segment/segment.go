@@ -0,0 +1,140 @@
+package segment
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/otus-murashko/banners-rotation/internal/config"
+	"github.com/otus-murashko/banners-rotation/internal/tenant"
+)
+
+// Attributes describe the visitor the banner is requested for.
+type Attributes struct {
+	Age         int
+	Gender      string
+	Device      string
+	Country     string
+	Region      string
+	OS          string
+	Language    string
+	Referrer    string
+	UTMSource   string
+	UTMMedium   string
+	UTMCampaign string
+}
+
+type Rule struct {
+	GroupID      int
+	AgeMin       int
+	AgeMax       int
+	Genders      []string
+	Devices      []string
+	Countries    []string
+	Referrers    []string
+	UTMSources   []string
+	UTMMediums   []string
+	UTMCampaigns []string
+}
+
+// Resolver maps request attributes to a social group of the tenant. Rules are
+// checked in order and the first matching rule wins; empty rule fields match
+// anything.
+type Resolver struct {
+	tenants map[string]tenantRules
+}
+
+type tenantRules struct {
+	rules        []Rule
+	defaultGroup int
+}
+
+// NewResolver takes the top-level rules for the default tenant and the rules
+// of the other tenants from their own sections.
+func NewResolver(conf config.Segmentation) Resolver {
+	tenants := make(map[string]tenantRules, len(conf.Tenants)+1)
+	tenants[tenant.Default] = newTenantRules(conf.DefaultGroup, conf.Rules)
+	for tenantID, tenantConf := range conf.Tenants {
+		tenants[tenantID] = newTenantRules(tenantConf.DefaultGroup, tenantConf.Rules)
+	}
+
+	return Resolver{tenants: tenants}
+}
+
+func newTenantRules(defaultGroup int, conf []config.SegmentRule) tenantRules {
+	rules := make([]Rule, 0, len(conf))
+	for _, r := range conf {
+		rules = append(rules, Rule{
+			GroupID:      r.GroupID,
+			AgeMin:       r.AgeMin,
+			AgeMax:       r.AgeMax,
+			Genders:      r.Genders,
+			Devices:      r.Devices,
+			Countries:    r.Countries,
+			Referrers:    r.Referrers,
+			UTMSources:   r.UTMSources,
+			UTMMediums:   r.UTMMediums,
+			UTMCampaigns: r.UTMCampaigns,
+		})
+	}
+
+	return tenantRules{rules: rules, defaultGroup: defaultGroup}
+}
+
+// Resolve returns the social group of the tenant for attrs, or false when no
+// rule of the tenant matches and the tenant has no default group. The rules of
+// one tenant never resolve the request of another.
+func (r Resolver) Resolve(tenantID string, attrs Attributes) (int, bool) {
+	t := r.tenants[tenantID]
+	for _, rule := range t.rules {
+		if rule.match(attrs) {
+			return rule.GroupID, true
+		}
+	}
+
+	return t.defaultGroup, t.defaultGroup != 0
+}
+
+func (rule Rule) match(attrs Attributes) bool {
+	if rule.AgeMin > 0 && (attrs.Age == 0 || attrs.Age < rule.AgeMin) {
+		return false
+	}
+	if rule.AgeMax > 0 && (attrs.Age == 0 || attrs.Age > rule.AgeMax) {
+		return false
+	}
+
+	return matchAny(rule.Genders, attrs.Gender) &&
+		matchAny(rule.Devices, attrs.Device) &&
+		matchAny(rule.Countries, attrs.Country) &&
+		matchReferrer(rule.Referrers, attrs.Referrer) &&
+		matchAny(rule.UTMSources, attrs.UTMSource) &&
+		matchAny(rule.UTMMediums, attrs.UTMMedium) &&
+		matchAny(rule.UTMCampaigns, attrs.UTMCampaign)
+}
+
+func matchAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(values, func(v string) bool {
+		return strings.EqualFold(v, value)
+	})
+}
+
+// matchReferrer checks whether the referrer host contains any of the patterns.
+func matchReferrer(patterns []string, referrer string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	host := referrer
+	if u, err := url.Parse(referrer); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	host = strings.ToLower(host)
+
+	return slices.ContainsFunc(patterns, func(p string) bool {
+		return p != "" && strings.Contains(host, strings.ToLower(p))
+	})
+}
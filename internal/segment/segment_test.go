@@ -0,0 +1,125 @@
+package segment
+
+import (
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/config"
+	"github.com/otus-murashko/banners-rotation/internal/tenant"
+)
+
+func TestResolve(t *testing.T) {
+	rules := []config.SegmentRule{
+		{GroupID: 1, AgeMin: 18, AgeMax: 25, Genders: []string{"female"}},
+		{GroupID: 2, Devices: []string{"mobile", "tablet"}, Countries: []string{"RU"}},
+		{GroupID: 3, Referrers: []string{"news"}},
+		{GroupID: 4, UTMSources: []string{"newsletter"}, UTMCampaigns: []string{"spring"}},
+	}
+
+	tests := []struct {
+		name         string
+		defaultGroup int
+		attrs        Attributes
+		wantGroup    int
+		wantOK       bool
+	}{
+		{
+			name:      "age and gender",
+			attrs:     Attributes{Age: 20, Gender: "Female"},
+			wantGroup: 1, wantOK: true,
+		},
+		{
+			name:  "age out of range",
+			attrs: Attributes{Age: 30, Gender: "female"},
+		},
+		{
+			name:  "age bound without age",
+			attrs: Attributes{Gender: "female"},
+		},
+		{
+			name:      "first matching rule wins",
+			attrs:     Attributes{Age: 18, Gender: "female", Device: "mobile", Country: "ru"},
+			wantGroup: 1, wantOK: true,
+		},
+		{
+			name:      "device and country",
+			attrs:     Attributes{Device: "TABLET", Country: "RU"},
+			wantGroup: 2, wantOK: true,
+		},
+		{
+			name:  "device of another country",
+			attrs: Attributes{Device: "mobile", Country: "DE"},
+		},
+		{
+			name:      "referrer host",
+			attrs:     Attributes{Referrer: "https://News.example.com/today?q=news"},
+			wantGroup: 3, wantOK: true,
+		},
+		{
+			name:  "referrer path is not matched",
+			attrs: Attributes{Referrer: "https://example.com/news"},
+		},
+		{
+			name:      "referrer without scheme",
+			attrs:     Attributes{Referrer: "daily-news.example.com"},
+			wantGroup: 3, wantOK: true,
+		},
+		{
+			name:      "utm",
+			attrs:     Attributes{UTMSource: "newsletter", UTMCampaign: "Spring"},
+			wantGroup: 4, wantOK: true,
+		},
+		{
+			name:  "partial utm",
+			attrs: Attributes{UTMSource: "newsletter"},
+		},
+		{
+			name:         "default group",
+			defaultGroup: 9,
+			attrs:        Attributes{Country: "DE"},
+			wantGroup:    9, wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewResolver(config.Segmentation{DefaultGroup: tt.defaultGroup, Rules: rules})
+
+			group, ok := r.Resolve(tenant.Default, tt.attrs)
+			if group != tt.wantGroup || ok != tt.wantOK {
+				t.Errorf("Resolve(%+v) = (%d, %v), want (%d, %v)", tt.attrs, group, ok, tt.wantGroup, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestResolveTenants(t *testing.T) {
+	r := NewResolver(config.Segmentation{
+		Rules: []config.SegmentRule{{GroupID: 1, Countries: []string{"RU"}}},
+		Tenants: map[string]config.TenantSegmentation{
+			"acme": {DefaultGroup: 8, Rules: []config.SegmentRule{{GroupID: 7, Countries: []string{"RU"}}}},
+		},
+	})
+
+	tests := []struct {
+		name      string
+		tenantID  string
+		attrs     Attributes
+		wantGroup int
+		wantOK    bool
+	}{
+		{name: "default tenant", tenantID: tenant.Default, attrs: Attributes{Country: "RU"}, wantGroup: 1, wantOK: true},
+		{name: "rule of the tenant", tenantID: "acme", attrs: Attributes{Country: "RU"}, wantGroup: 7, wantOK: true},
+		{name: "default group of the tenant", tenantID: "acme", attrs: Attributes{Country: "DE"}, wantGroup: 8, wantOK: true},
+		{name: "tenant without rules", tenantID: "other", attrs: Attributes{Country: "RU"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group, ok := r.Resolve(tt.tenantID, tt.attrs)
+			if group != tt.wantGroup || ok != tt.wantOK {
+				t.Errorf("Resolve(%q, %+v) = (%d, %v), want (%d, %v)",
+					tt.tenantID, tt.attrs, group, ok, tt.wantGroup, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package internalgrpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/otus-murashko/banners-rotation/internal/app"
+	"github.com/otus-murashko/banners-rotation/internal/auth"
+	"github.com/otus-murashko/banners-rotation/internal/server/grpc/pb"
+	"github.com/otus-murashko/banners-rotation/internal/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// methodScopes are the scopes of the calls, the calls missing here need the
+// admin scope.
+var methodScopes = map[string]auth.Scope{
+	pb.BannersRotation_CreateBanner_FullMethodName:         auth.ScopeAdmin,
+	pb.BannersRotation_CreateSlot_FullMethodName:           auth.ScopeAdmin,
+	pb.BannersRotation_CreateGroup_FullMethodName:          auth.ScopeAdmin,
+	pb.BannersRotation_AddBannerToSlot_FullMethodName:      auth.ScopeAdmin,
+	pb.BannersRotation_DeleteBannerFromSlot_FullMethodName: auth.ScopeAdmin,
+	pb.BannersRotation_GetBannersBySlot_FullMethodName:     auth.ScopeRead,
+	pb.BannersRotation_GetBanner_FullMethodName:            auth.ScopeTrack,
+	pb.BannersRotation_GetSlotsBanners_FullMethodName:      auth.ScopeTrack,
+	pb.BannersRotation_RecordClick_FullMethodName:          auth.ScopeTrack,
+	pb.BannersRotation_RecordEvents_FullMethodName:         auth.ScopeTrack,
+	pb.BannersRotation_TrackConversion_FullMethodName:      auth.ScopeTrack,
+	pb.BannersRotation_GetStats_FullMethodName:             auth.ScopeRead,
+	pb.BannersRotation_CreateExperiment_FullMethodName:     auth.ScopeAdmin,
+	pb.BannersRotation_GetExperimentReport_FullMethodName:  auth.ScopeRead,
+	pb.BannersRotation_GetRevenueReport_FullMethodName:     auth.ScopeRead,
+}
+
+// tenantInterceptor scopes the call to the tenant of its API key or, without
+// authentication, to the default tenant.
+func tenantInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	requested := ""
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(tenant.Header); len(values) > 0 {
+		requested = values[0]
+	}
+
+	apiKey, authenticated := auth.KeyFrom(ctx)
+
+	tenantID, err := tenant.Resolve(apiKey.Tenant, authenticated, requested)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return handler(tenant.WithTenant(ctx, tenantID), req)
+}
+
+// authInterceptor requires an API key with the call scope, sent either as a
+// bearer token in the authorization metadata or in x-api-key.
+func authInterceptor(a app.Application) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		scope, ok := methodScopes[info.FullMethod]
+		if !ok {
+			scope = auth.ScopeAdmin
+		}
+
+		apiKey, err := a.Authenticate(ctx, requestKey(ctx), scope)
+		if err != nil {
+			return nil, toStatus(ctx, err)
+		}
+
+		return handler(auth.WithKey(ctx, apiKey), req)
+	}
+}
+
+func requestKey(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	for _, value := range md.Get("authorization") {
+		if bearer, ok := strings.CutPrefix(value, "Bearer "); ok {
+			return strings.TrimSpace(bearer)
+		}
+	}
+
+	if keys := md.Get("x-api-key"); len(keys) > 0 {
+		return keys[0]
+	}
+
+	return ""
+}
@@ -0,0 +1,76 @@
+package internalgrpc
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// toStatus converts domain errors to gRPC statuses the same way the HTTP API
+// maps them to status codes. Field errors are sent as BadRequest details.
+func toStatus(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	kind := apperror.KindOf(err)
+	if kind == apperror.KindInternal {
+		slog.ErrorContext(ctx, "internal error", "error", err)
+	}
+
+	st := status.New(errorCode(kind), apperror.MessageOf(err))
+
+	if retryAfter, ok := apperror.RetryAfterOf(err); ok {
+		detailed, detailsErr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+		if detailsErr != nil {
+			return st.Err()
+		}
+		return detailed.Err()
+	}
+
+	fields := apperror.FieldsOf(err)
+	if len(fields) == 0 {
+		return st.Err()
+	}
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(fields))
+	for field, description := range fields {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: description,
+		})
+	}
+
+	detailed, detailsErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if detailsErr != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
+func errorCode(kind apperror.Kind) codes.Code {
+	switch kind {
+	case apperror.KindNotFound:
+		return codes.NotFound
+	case apperror.KindConflict:
+		return codes.AlreadyExists
+	case apperror.KindValidation:
+		return codes.InvalidArgument
+	case apperror.KindUnauthenticated:
+		return codes.Unauthenticated
+	case apperror.KindPermissionDenied:
+		return codes.PermissionDenied
+	case apperror.KindRateLimited:
+		return codes.ResourceExhausted
+	case apperror.KindUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,96 @@
+package internalgrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    codes.Code
+		wantMessage string
+	}{
+		{name: "not found", err: apperror.NotFound("banner 1 not found"), wantCode: codes.NotFound, wantMessage: "banner 1 not found"},
+		{name: "conflict", err: apperror.Conflict("already exists"), wantCode: codes.AlreadyExists, wantMessage: "already exists"},
+		{name: "validation", err: apperror.Validation("slot_id is required"), wantCode: codes.InvalidArgument, wantMessage: "slot_id is required"},
+		{name: "unauthenticated", err: apperror.Unauthenticated("API key is required"), wantCode: codes.Unauthenticated, wantMessage: "API key is required"},
+		{name: "permission denied", err: apperror.PermissionDenied("no scope"), wantCode: codes.PermissionDenied, wantMessage: "no scope"},
+		{name: "unavailable", err: apperror.Unavailable("database is down"), wantCode: codes.Unavailable, wantMessage: "database is down"},
+		{
+			name:        "wrapped domain error",
+			err:         fmt.Errorf("get banner: %w", apperror.NotFound("banner 1 not found")),
+			wantCode:    codes.NotFound,
+			wantMessage: "banner 1 not found",
+		},
+		{name: "internal error is hidden", err: errors.New("connection refused"), wantCode: codes.Internal, wantMessage: "internal error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(toStatus(context.Background(), tt.err))
+			if st.Code() != tt.wantCode || st.Message() != tt.wantMessage {
+				t.Errorf("toStatus() = %s %q, want %s %q", st.Code(), st.Message(), tt.wantCode, tt.wantMessage)
+			}
+			if len(st.Details()) != 0 {
+				t.Errorf("toStatus() details = %v, want none", st.Details())
+			}
+		})
+	}
+
+	if err := toStatus(context.Background(), nil); err != nil {
+		t.Errorf("toStatus(nil) = %v, want nil", err)
+	}
+}
+
+func TestToStatusDetails(t *testing.T) {
+	t.Run("retry info", func(t *testing.T) {
+		st := status.Convert(toStatus(context.Background(), apperror.RateLimited(2*time.Second)))
+		if st.Code() != codes.ResourceExhausted {
+			t.Fatalf("code = %s, want %s", st.Code(), codes.ResourceExhausted)
+		}
+
+		details := st.Details()
+		if len(details) != 1 {
+			t.Fatalf("details = %v, want the retry info", details)
+		}
+		info, ok := details[0].(*errdetails.RetryInfo)
+		if !ok || info.GetRetryDelay().AsDuration() != 2*time.Second {
+			t.Errorf("details = %v, want a retry delay of 2s", details[0])
+		}
+	})
+
+	t.Run("field violations", func(t *testing.T) {
+		fields := map[string]string{"SlotID": "must be positive", "BannerID": "must be positive"}
+		st := status.Convert(toStatus(context.Background(), apperror.InvalidFields(fields)))
+		if st.Code() != codes.InvalidArgument {
+			t.Fatalf("code = %s, want %s", st.Code(), codes.InvalidArgument)
+		}
+
+		details := st.Details()
+		if len(details) != 1 {
+			t.Fatalf("details = %v, want the bad request", details)
+		}
+		badRequest, ok := details[0].(*errdetails.BadRequest)
+		if !ok {
+			t.Fatalf("details = %v, want the bad request", details[0])
+		}
+
+		got := make(map[string]string)
+		for _, violation := range badRequest.GetFieldViolations() {
+			got[violation.GetField()] = violation.GetDescription()
+		}
+		if len(got) != len(fields) || got["SlotID"] != fields["SlotID"] || got["BannerID"] != fields["BannerID"] {
+			t.Errorf("violations = %v, want %v", got, fields)
+		}
+	})
+}
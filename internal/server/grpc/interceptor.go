@@ -0,0 +1,78 @@
+package internalgrpc
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/logger"
+	"github.com/otus-murashko/banners-rotation/internal/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+var requestIDKey = strings.ToLower(logger.RequestIDHeader)
+
+// requestIDInterceptor takes the request ID from the call metadata or
+// generates one, returns it in the response header and passes it on in the
+// context.
+func requestIDInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDKey); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	if !logger.ValidRequestID(id) {
+		id = logger.NewRequestID()
+	}
+
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+
+	return handler(logger.WithRequestID(ctx, id), req)
+}
+
+// serverError reports whether the code is a failure of the service rather
+// than of the request.
+func serverError(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unavailable, codes.Unknown, codes.DataLoss:
+		return true
+	}
+	return false
+}
+
+func loggingInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	startTime := time.Now()
+	resp, err := handler(ctx, req)
+	elapsed := time.Since(startTime)
+
+	addr := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr.String()
+	}
+
+	code := status.Code(err)
+	level := slog.LevelInfo
+	if serverError(code) {
+		level = slog.LevelError
+	}
+
+	slog.LogAttrs(ctx, level, "call",
+		slog.String("remote_addr", addr),
+		slog.String("method", info.FullMethod),
+		slog.String("code", code.String()),
+		slog.Duration("elapsed", elapsed),
+	)
+	metrics.ObserveGRPC(info.FullMethod, code.String(), elapsed)
+
+	return resp, err
+}
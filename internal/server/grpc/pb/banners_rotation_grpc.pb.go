@@ -0,0 +1,667 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: bannersrotation/v1/banners_rotation.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	BannersRotation_CreateBanner_FullMethodName         = "/bannersrotation.v1.BannersRotation/CreateBanner"
+	BannersRotation_CreateSlot_FullMethodName           = "/bannersrotation.v1.BannersRotation/CreateSlot"
+	BannersRotation_CreateGroup_FullMethodName          = "/bannersrotation.v1.BannersRotation/CreateGroup"
+	BannersRotation_AddBannerToSlot_FullMethodName      = "/bannersrotation.v1.BannersRotation/AddBannerToSlot"
+	BannersRotation_DeleteBannerFromSlot_FullMethodName = "/bannersrotation.v1.BannersRotation/DeleteBannerFromSlot"
+	BannersRotation_GetBannersBySlot_FullMethodName     = "/bannersrotation.v1.BannersRotation/GetBannersBySlot"
+	BannersRotation_GetBanner_FullMethodName            = "/bannersrotation.v1.BannersRotation/GetBanner"
+	BannersRotation_GetSlotsBanners_FullMethodName      = "/bannersrotation.v1.BannersRotation/GetSlotsBanners"
+	BannersRotation_RecordClick_FullMethodName          = "/bannersrotation.v1.BannersRotation/RecordClick"
+	BannersRotation_RecordEvents_FullMethodName         = "/bannersrotation.v1.BannersRotation/RecordEvents"
+	BannersRotation_TrackConversion_FullMethodName      = "/bannersrotation.v1.BannersRotation/TrackConversion"
+	BannersRotation_GetStats_FullMethodName             = "/bannersrotation.v1.BannersRotation/GetStats"
+	BannersRotation_CreateExperiment_FullMethodName     = "/bannersrotation.v1.BannersRotation/CreateExperiment"
+	BannersRotation_GetExperimentReport_FullMethodName  = "/bannersrotation.v1.BannersRotation/GetExperimentReport"
+	BannersRotation_GetRevenueReport_FullMethodName     = "/bannersrotation.v1.BannersRotation/GetRevenueReport"
+)
+
+// BannersRotationClient is the client API for BannersRotation service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// BannersRotation exposes the operations of the HTTP API for backend callers.
+type BannersRotationClient interface {
+	CreateBanner(ctx context.Context, in *CreateBannerRequest, opts ...grpc.CallOption) (*Banner, error)
+	CreateSlot(ctx context.Context, in *CreateSlotRequest, opts ...grpc.CallOption) (*Slot, error)
+	CreateGroup(ctx context.Context, in *CreateGroupRequest, opts ...grpc.CallOption) (*SocialGroup, error)
+	AddBannerToSlot(ctx context.Context, in *AddBannerToSlotRequest, opts ...grpc.CallOption) (*AddBannerToSlotResponse, error)
+	DeleteBannerFromSlot(ctx context.Context, in *DeleteBannerFromSlotRequest, opts ...grpc.CallOption) (*DeleteBannerFromSlotResponse, error)
+	GetBannersBySlot(ctx context.Context, in *GetBannersBySlotRequest, opts ...grpc.CallOption) (*GetBannersBySlotResponse, error)
+	// GetBanner selects a banner for the slot and counts its show, there is no
+	// separate call to record shows.
+	GetBanner(ctx context.Context, in *GetBannerRequest, opts ...grpc.CallOption) (*GetBannerResponse, error)
+	// GetSlotsBanners selects banners for several slots of one page.
+	GetSlotsBanners(ctx context.Context, in *GetSlotsBannersRequest, opts ...grpc.CallOption) (*GetSlotsBannersResponse, error)
+	RecordClick(ctx context.Context, in *RecordClickRequest, opts ...grpc.CallOption) (*RecordClickResponse, error)
+	// RecordEvents applies shows and clicks in bulk, the batch is rejected as a
+	// whole when any of the events is invalid.
+	RecordEvents(ctx context.Context, in *RecordEventsRequest, opts ...grpc.CallOption) (*RecordEventsResponse, error)
+	TrackConversion(ctx context.Context, in *TrackConversionRequest, opts ...grpc.CallOption) (*TrackConversionResponse, error)
+	GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error)
+	CreateExperiment(ctx context.Context, in *CreateExperimentRequest, opts ...grpc.CallOption) (*Experiment, error)
+	GetExperimentReport(ctx context.Context, in *GetExperimentReportRequest, opts ...grpc.CallOption) (*GetExperimentReportResponse, error)
+	GetRevenueReport(ctx context.Context, in *GetRevenueReportRequest, opts ...grpc.CallOption) (*GetRevenueReportResponse, error)
+}
+
+type bannersRotationClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBannersRotationClient(cc grpc.ClientConnInterface) BannersRotationClient {
+	return &bannersRotationClient{cc}
+}
+
+func (c *bannersRotationClient) CreateBanner(ctx context.Context, in *CreateBannerRequest, opts ...grpc.CallOption) (*Banner, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Banner)
+	err := c.cc.Invoke(ctx, BannersRotation_CreateBanner_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bannersRotationClient) CreateSlot(ctx context.Context, in *CreateSlotRequest, opts ...grpc.CallOption) (*Slot, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Slot)
+	err := c.cc.Invoke(ctx, BannersRotation_CreateSlot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bannersRotationClient) CreateGroup(ctx context.Context, in *CreateGroupRequest, opts ...grpc.CallOption) (*SocialGroup, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SocialGroup)
+	err := c.cc.Invoke(ctx, BannersRotation_CreateGroup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bannersRotationClient) AddBannerToSlot(ctx context.Context, in *AddBannerToSlotRequest, opts ...grpc.CallOption) (*AddBannerToSlotResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddBannerToSlotResponse)
+	err := c.cc.Invoke(ctx, BannersRotation_AddBannerToSlot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bannersRotationClient) DeleteBannerFromSlot(ctx context.Context, in *DeleteBannerFromSlotRequest, opts ...grpc.CallOption) (*DeleteBannerFromSlotResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteBannerFromSlotResponse)
+	err := c.cc.Invoke(ctx, BannersRotation_DeleteBannerFromSlot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bannersRotationClient) GetBannersBySlot(ctx context.Context, in *GetBannersBySlotRequest, opts ...grpc.CallOption) (*GetBannersBySlotResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetBannersBySlotResponse)
+	err := c.cc.Invoke(ctx, BannersRotation_GetBannersBySlot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bannersRotationClient) GetBanner(ctx context.Context, in *GetBannerRequest, opts ...grpc.CallOption) (*GetBannerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetBannerResponse)
+	err := c.cc.Invoke(ctx, BannersRotation_GetBanner_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bannersRotationClient) GetSlotsBanners(ctx context.Context, in *GetSlotsBannersRequest, opts ...grpc.CallOption) (*GetSlotsBannersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSlotsBannersResponse)
+	err := c.cc.Invoke(ctx, BannersRotation_GetSlotsBanners_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bannersRotationClient) RecordClick(ctx context.Context, in *RecordClickRequest, opts ...grpc.CallOption) (*RecordClickResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RecordClickResponse)
+	err := c.cc.Invoke(ctx, BannersRotation_RecordClick_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bannersRotationClient) RecordEvents(ctx context.Context, in *RecordEventsRequest, opts ...grpc.CallOption) (*RecordEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RecordEventsResponse)
+	err := c.cc.Invoke(ctx, BannersRotation_RecordEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bannersRotationClient) TrackConversion(ctx context.Context, in *TrackConversionRequest, opts ...grpc.CallOption) (*TrackConversionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TrackConversionResponse)
+	err := c.cc.Invoke(ctx, BannersRotation_TrackConversion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bannersRotationClient) GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStatsResponse)
+	err := c.cc.Invoke(ctx, BannersRotation_GetStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bannersRotationClient) CreateExperiment(ctx context.Context, in *CreateExperimentRequest, opts ...grpc.CallOption) (*Experiment, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Experiment)
+	err := c.cc.Invoke(ctx, BannersRotation_CreateExperiment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bannersRotationClient) GetExperimentReport(ctx context.Context, in *GetExperimentReportRequest, opts ...grpc.CallOption) (*GetExperimentReportResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetExperimentReportResponse)
+	err := c.cc.Invoke(ctx, BannersRotation_GetExperimentReport_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bannersRotationClient) GetRevenueReport(ctx context.Context, in *GetRevenueReportRequest, opts ...grpc.CallOption) (*GetRevenueReportResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRevenueReportResponse)
+	err := c.cc.Invoke(ctx, BannersRotation_GetRevenueReport_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BannersRotationServer is the server API for BannersRotation service.
+// All implementations must embed UnimplementedBannersRotationServer
+// for forward compatibility.
+//
+// BannersRotation exposes the operations of the HTTP API for backend callers.
+type BannersRotationServer interface {
+	CreateBanner(context.Context, *CreateBannerRequest) (*Banner, error)
+	CreateSlot(context.Context, *CreateSlotRequest) (*Slot, error)
+	CreateGroup(context.Context, *CreateGroupRequest) (*SocialGroup, error)
+	AddBannerToSlot(context.Context, *AddBannerToSlotRequest) (*AddBannerToSlotResponse, error)
+	DeleteBannerFromSlot(context.Context, *DeleteBannerFromSlotRequest) (*DeleteBannerFromSlotResponse, error)
+	GetBannersBySlot(context.Context, *GetBannersBySlotRequest) (*GetBannersBySlotResponse, error)
+	// GetBanner selects a banner for the slot and counts its show, there is no
+	// separate call to record shows.
+	GetBanner(context.Context, *GetBannerRequest) (*GetBannerResponse, error)
+	// GetSlotsBanners selects banners for several slots of one page.
+	GetSlotsBanners(context.Context, *GetSlotsBannersRequest) (*GetSlotsBannersResponse, error)
+	RecordClick(context.Context, *RecordClickRequest) (*RecordClickResponse, error)
+	// RecordEvents applies shows and clicks in bulk, the batch is rejected as a
+	// whole when any of the events is invalid.
+	RecordEvents(context.Context, *RecordEventsRequest) (*RecordEventsResponse, error)
+	TrackConversion(context.Context, *TrackConversionRequest) (*TrackConversionResponse, error)
+	GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error)
+	CreateExperiment(context.Context, *CreateExperimentRequest) (*Experiment, error)
+	GetExperimentReport(context.Context, *GetExperimentReportRequest) (*GetExperimentReportResponse, error)
+	GetRevenueReport(context.Context, *GetRevenueReportRequest) (*GetRevenueReportResponse, error)
+	mustEmbedUnimplementedBannersRotationServer()
+}
+
+// UnimplementedBannersRotationServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBannersRotationServer struct{}
+
+func (UnimplementedBannersRotationServer) CreateBanner(context.Context, *CreateBannerRequest) (*Banner, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateBanner not implemented")
+}
+func (UnimplementedBannersRotationServer) CreateSlot(context.Context, *CreateSlotRequest) (*Slot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSlot not implemented")
+}
+func (UnimplementedBannersRotationServer) CreateGroup(context.Context, *CreateGroupRequest) (*SocialGroup, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateGroup not implemented")
+}
+func (UnimplementedBannersRotationServer) AddBannerToSlot(context.Context, *AddBannerToSlotRequest) (*AddBannerToSlotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddBannerToSlot not implemented")
+}
+func (UnimplementedBannersRotationServer) DeleteBannerFromSlot(context.Context, *DeleteBannerFromSlotRequest) (*DeleteBannerFromSlotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteBannerFromSlot not implemented")
+}
+func (UnimplementedBannersRotationServer) GetBannersBySlot(context.Context, *GetBannersBySlotRequest) (*GetBannersBySlotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBannersBySlot not implemented")
+}
+func (UnimplementedBannersRotationServer) GetBanner(context.Context, *GetBannerRequest) (*GetBannerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBanner not implemented")
+}
+func (UnimplementedBannersRotationServer) GetSlotsBanners(context.Context, *GetSlotsBannersRequest) (*GetSlotsBannersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSlotsBanners not implemented")
+}
+func (UnimplementedBannersRotationServer) RecordClick(context.Context, *RecordClickRequest) (*RecordClickResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecordClick not implemented")
+}
+func (UnimplementedBannersRotationServer) RecordEvents(context.Context, *RecordEventsRequest) (*RecordEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecordEvents not implemented")
+}
+func (UnimplementedBannersRotationServer) TrackConversion(context.Context, *TrackConversionRequest) (*TrackConversionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TrackConversion not implemented")
+}
+func (UnimplementedBannersRotationServer) GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedBannersRotationServer) CreateExperiment(context.Context, *CreateExperimentRequest) (*Experiment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateExperiment not implemented")
+}
+func (UnimplementedBannersRotationServer) GetExperimentReport(context.Context, *GetExperimentReportRequest) (*GetExperimentReportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetExperimentReport not implemented")
+}
+func (UnimplementedBannersRotationServer) GetRevenueReport(context.Context, *GetRevenueReportRequest) (*GetRevenueReportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRevenueReport not implemented")
+}
+func (UnimplementedBannersRotationServer) mustEmbedUnimplementedBannersRotationServer() {}
+func (UnimplementedBannersRotationServer) testEmbeddedByValue()                         {}
+
+// UnsafeBannersRotationServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BannersRotationServer will
+// result in compilation errors.
+type UnsafeBannersRotationServer interface {
+	mustEmbedUnimplementedBannersRotationServer()
+}
+
+func RegisterBannersRotationServer(s grpc.ServiceRegistrar, srv BannersRotationServer) {
+	// If the following call pancis, it indicates UnimplementedBannersRotationServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&BannersRotation_ServiceDesc, srv)
+}
+
+func _BannersRotation_CreateBanner_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateBannerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BannersRotationServer).CreateBanner(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BannersRotation_CreateBanner_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BannersRotationServer).CreateBanner(ctx, req.(*CreateBannerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BannersRotation_CreateSlot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSlotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BannersRotationServer).CreateSlot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BannersRotation_CreateSlot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BannersRotationServer).CreateSlot(ctx, req.(*CreateSlotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BannersRotation_CreateGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateGroupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BannersRotationServer).CreateGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BannersRotation_CreateGroup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BannersRotationServer).CreateGroup(ctx, req.(*CreateGroupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BannersRotation_AddBannerToSlot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddBannerToSlotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BannersRotationServer).AddBannerToSlot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BannersRotation_AddBannerToSlot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BannersRotationServer).AddBannerToSlot(ctx, req.(*AddBannerToSlotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BannersRotation_DeleteBannerFromSlot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteBannerFromSlotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BannersRotationServer).DeleteBannerFromSlot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BannersRotation_DeleteBannerFromSlot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BannersRotationServer).DeleteBannerFromSlot(ctx, req.(*DeleteBannerFromSlotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BannersRotation_GetBannersBySlot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBannersBySlotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BannersRotationServer).GetBannersBySlot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BannersRotation_GetBannersBySlot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BannersRotationServer).GetBannersBySlot(ctx, req.(*GetBannersBySlotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BannersRotation_GetBanner_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBannerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BannersRotationServer).GetBanner(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BannersRotation_GetBanner_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BannersRotationServer).GetBanner(ctx, req.(*GetBannerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BannersRotation_GetSlotsBanners_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSlotsBannersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BannersRotationServer).GetSlotsBanners(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BannersRotation_GetSlotsBanners_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BannersRotationServer).GetSlotsBanners(ctx, req.(*GetSlotsBannersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BannersRotation_RecordClick_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordClickRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BannersRotationServer).RecordClick(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BannersRotation_RecordClick_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BannersRotationServer).RecordClick(ctx, req.(*RecordClickRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BannersRotation_RecordEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BannersRotationServer).RecordEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BannersRotation_RecordEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BannersRotationServer).RecordEvents(ctx, req.(*RecordEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BannersRotation_TrackConversion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TrackConversionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BannersRotationServer).TrackConversion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BannersRotation_TrackConversion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BannersRotationServer).TrackConversion(ctx, req.(*TrackConversionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BannersRotation_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BannersRotationServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BannersRotation_GetStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BannersRotationServer).GetStats(ctx, req.(*GetStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BannersRotation_CreateExperiment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateExperimentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BannersRotationServer).CreateExperiment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BannersRotation_CreateExperiment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BannersRotationServer).CreateExperiment(ctx, req.(*CreateExperimentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BannersRotation_GetExperimentReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetExperimentReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BannersRotationServer).GetExperimentReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BannersRotation_GetExperimentReport_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BannersRotationServer).GetExperimentReport(ctx, req.(*GetExperimentReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BannersRotation_GetRevenueReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRevenueReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BannersRotationServer).GetRevenueReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BannersRotation_GetRevenueReport_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BannersRotationServer).GetRevenueReport(ctx, req.(*GetRevenueReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BannersRotation_ServiceDesc is the grpc.ServiceDesc for BannersRotation service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BannersRotation_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bannersrotation.v1.BannersRotation",
+	HandlerType: (*BannersRotationServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateBanner",
+			Handler:    _BannersRotation_CreateBanner_Handler,
+		},
+		{
+			MethodName: "CreateSlot",
+			Handler:    _BannersRotation_CreateSlot_Handler,
+		},
+		{
+			MethodName: "CreateGroup",
+			Handler:    _BannersRotation_CreateGroup_Handler,
+		},
+		{
+			MethodName: "AddBannerToSlot",
+			Handler:    _BannersRotation_AddBannerToSlot_Handler,
+		},
+		{
+			MethodName: "DeleteBannerFromSlot",
+			Handler:    _BannersRotation_DeleteBannerFromSlot_Handler,
+		},
+		{
+			MethodName: "GetBannersBySlot",
+			Handler:    _BannersRotation_GetBannersBySlot_Handler,
+		},
+		{
+			MethodName: "GetBanner",
+			Handler:    _BannersRotation_GetBanner_Handler,
+		},
+		{
+			MethodName: "GetSlotsBanners",
+			Handler:    _BannersRotation_GetSlotsBanners_Handler,
+		},
+		{
+			MethodName: "RecordClick",
+			Handler:    _BannersRotation_RecordClick_Handler,
+		},
+		{
+			MethodName: "RecordEvents",
+			Handler:    _BannersRotation_RecordEvents_Handler,
+		},
+		{
+			MethodName: "TrackConversion",
+			Handler:    _BannersRotation_TrackConversion_Handler,
+		},
+		{
+			MethodName: "GetStats",
+			Handler:    _BannersRotation_GetStats_Handler,
+		},
+		{
+			MethodName: "CreateExperiment",
+			Handler:    _BannersRotation_CreateExperiment_Handler,
+		},
+		{
+			MethodName: "GetExperimentReport",
+			Handler:    _BannersRotation_GetExperimentReport_Handler,
+		},
+		{
+			MethodName: "GetRevenueReport",
+			Handler:    _BannersRotation_GetRevenueReport_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "bannersrotation/v1/banners_rotation.proto",
+}
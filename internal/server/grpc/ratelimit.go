@@ -0,0 +1,48 @@
+package internalgrpc
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/auth"
+	"github.com/otus-murashko/banners-rotation/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// rateLimitInterceptor refuses the track calls of the clients that ran out of
+// tokens. clientKey picks the bucket of the call.
+func rateLimitInterceptor(limiter *ratelimit.Limiter, clientKey func(ctx context.Context) string,
+) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if methodScopes[info.FullMethod] != auth.ScopeTrack {
+			return handler(ctx, req)
+		}
+
+		if ok, retryAfter := limiter.Allow(clientKey(ctx)); !ok {
+			return nil, toStatus(ctx, apperror.RateLimited(retryAfter))
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+func apiKeyID(ctx context.Context) string {
+	apiKey, _ := auth.KeyFrom(ctx)
+	return strconv.Itoa(apiKey.ID)
+}
@@ -0,0 +1,83 @@
+package internalgrpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/otus-murashko/banners-rotation/internal/app"
+	"github.com/otus-murashko/banners-rotation/internal/config"
+	"github.com/otus-murashko/banners-rotation/internal/ratelimit"
+	"github.com/otus-murashko/banners-rotation/internal/server/grpc/pb"
+	"google.golang.org/grpc"
+)
+
+type Server struct {
+	server *grpc.Server
+	app    app.Application
+	addr   string
+
+	keyLimiter *ratelimit.Limiter
+	ipLimiter  *ratelimit.Limiter
+}
+
+func NewServer(app app.Application, conf config.GRPCServer) *Server {
+	// the limiters are kept for SetRateLimit even when they are disabled
+	keyLimiter := ratelimit.New(conf.RateLimit.PerKey.Rate, conf.RateLimit.PerKey.Burst)
+	ipLimiter := ratelimit.New(conf.RateLimit.PerIP.Rate, conf.RateLimit.PerIP.Burst)
+
+	interceptors := []grpc.UnaryServerInterceptor{
+		requestIDInterceptor, tracingInterceptor, loggingInterceptor,
+		rateLimitInterceptor(ipLimiter, peerIP),
+	}
+	if conf.Auth {
+		interceptors = append(interceptors, authInterceptor(app), rateLimitInterceptor(keyLimiter, apiKeyID))
+	}
+	interceptors = append(interceptors, tenantInterceptor)
+
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(interceptors...),
+	)
+	pb.RegisterBannersRotationServer(grpcServer, &service{app: app})
+
+	return &Server{
+		server:     grpcServer,
+		app:        app,
+		addr:       fmt.Sprintf("%s:%d", conf.Host, conf.Port),
+		keyLimiter: keyLimiter,
+		ipLimiter:  ipLimiter,
+	}
+}
+
+// SetRateLimit applies new limits to the calls in flight and to come.
+func (s *Server) SetRateLimit(conf config.RateLimit) {
+	s.keyLimiter.SetLimit(conf.PerKey.Rate, conf.PerKey.Burst)
+	s.ipLimiter.SetLimit(conf.PerIP.Rate, conf.PerIP.Burst)
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	lis, err := (&net.ListenConfig{}).Listen(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	return s.server.Serve(lis)
+}
+
+// Stop waits for the running calls until ctx is done and then closes the
+// remaining connections.
+func (s *Server) Stop(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,354 @@
+package internalgrpc
+
+import (
+	"context"
+
+	"github.com/otus-murashko/banners-rotation/internal/app"
+	"github.com/otus-murashko/banners-rotation/internal/segment"
+	"github.com/otus-murashko/banners-rotation/internal/server/grpc/pb"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+	"google.golang.org/grpc/metadata"
+)
+
+type service struct {
+	pb.UnimplementedBannersRotationServer
+	app app.Application
+}
+
+func (s *service) CreateBanner(ctx context.Context, req *pb.CreateBannerRequest) (*pb.Banner, error) {
+	banner := storage.Banner{
+		Descr:   req.GetDescr(),
+		Pricing: req.GetPricing(),
+		Bid:     req.GetBid(),
+	}
+
+	id, err := s.app.CreateBanner(ctx, banner)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	banner.ID = id
+	if banner.Pricing == "" {
+		banner.Pricing = storage.PricingCPM
+	}
+
+	return toPbBanner(banner), nil
+}
+
+func (s *service) CreateSlot(ctx context.Context, req *pb.CreateSlotRequest) (*pb.Slot, error) {
+	id, err := s.app.CreateSlot(ctx, req.GetDescr())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &pb.Slot{Id: int64(id), Descr: req.GetDescr()}, nil
+}
+
+func (s *service) CreateGroup(ctx context.Context, req *pb.CreateGroupRequest) (*pb.SocialGroup, error) {
+	id, err := s.app.CreateGroup(ctx, req.GetDescr())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &pb.SocialGroup{Id: int64(id), Descr: req.GetDescr()}, nil
+}
+
+func (s *service) AddBannerToSlot(ctx context.Context, req *pb.AddBannerToSlotRequest,
+) (*pb.AddBannerToSlotResponse, error) {
+	t := req.GetTargeting()
+	rotation := storage.Rotation{
+		BannerID: int(req.GetBannerId()),
+		SlotID:   int(req.GetSlotId()),
+		Targeting: storage.Targeting{
+			Countries: t.GetCountries(),
+			Regions:   t.GetRegions(),
+			Devices:   t.GetDevices(),
+			OS:        t.GetOs(),
+			Languages: t.GetLanguages(),
+		},
+	}
+
+	if err := s.app.AddBannerToSlot(ctx, rotation); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &pb.AddBannerToSlotResponse{}, nil
+}
+
+func (s *service) DeleteBannerFromSlot(ctx context.Context, req *pb.DeleteBannerFromSlotRequest,
+) (*pb.DeleteBannerFromSlotResponse, error) {
+	if err := s.app.DeleteBannerFromSlot(ctx, int(req.GetBannerId()), int(req.GetSlotId())); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &pb.DeleteBannerFromSlotResponse{}, nil
+}
+
+func (s *service) GetBannersBySlot(ctx context.Context, req *pb.GetBannersBySlotRequest,
+) (*pb.GetBannersBySlotResponse, error) {
+	bannerIDs, err := s.app.GetBannersBySlot(ctx, int(req.GetSlotId()))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &pb.GetBannersBySlotResponse{BannerIds: toInt64s(bannerIDs)}, nil
+}
+
+func (s *service) GetBanner(ctx context.Context, req *pb.GetBannerRequest) (*pb.GetBannerResponse, error) {
+	attrs := fromPbAttributes(req.GetAttributes())
+
+	rotation, err := s.app.GetBannerRotation(ctx, int(req.GetSlotId()), int(req.GetGroupId()), attrs)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &pb.GetBannerResponse{
+		Banner:       toPbBanner(rotation.Banner),
+		ExperimentId: int64(rotation.ExperimentID),
+		Arm:          rotation.Arm,
+		ImpressionId: rotation.ImpressionID,
+	}, nil
+}
+
+func (s *service) GetSlotsBanners(ctx context.Context, req *pb.GetSlotsBannersRequest) (*pb.GetSlotsBannersResponse, error) {
+	slotIDs := toInts(req.GetSlotIds())
+	attrs := fromPbAttributes(req.GetAttributes())
+
+	rotations, err := s.app.GetBannerRotations(ctx, slotIDs, int(req.GetGroupId()), attrs, req.GetUnique())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	resp := &pb.GetSlotsBannersResponse{Banners: make([]*pb.SlotBanner, 0, len(rotations))}
+	for _, slotID := range slotIDs {
+		rotation, ok := rotations[slotID]
+		if !ok {
+			continue
+		}
+		resp.Banners = append(resp.Banners, &pb.SlotBanner{
+			SlotId:       int64(slotID),
+			Banner:       toPbBanner(rotation.Banner),
+			ExperimentId: int64(rotation.ExperimentID),
+			Arm:          rotation.Arm,
+			ImpressionId: rotation.ImpressionID,
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *service) RecordClick(ctx context.Context, req *pb.RecordClickRequest) (*pb.RecordClickResponse, error) {
+	stat := storage.Statistic{
+		BannerID:      int(req.GetBannerId()),
+		SlotID:        int(req.GetSlotId()),
+		SosialGroupID: int(req.GetGroupId()),
+		ExperimentID:  int(req.GetExperimentId()),
+		Arm:           req.GetArm(),
+		ImpressionID:  req.GetImpressionId(),
+	}
+
+	if err := s.app.UpdateClickStat(ctx, stat, callClient(ctx)); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &pb.RecordClickResponse{}, nil
+}
+
+func (s *service) RecordEvents(ctx context.Context, req *pb.RecordEventsRequest) (*pb.RecordEventsResponse, error) {
+	events := make([]storage.Event, 0, len(req.GetEvents()))
+	for _, e := range req.GetEvents() {
+		event := storage.Event{
+			Type:          e.GetType(),
+			BannerID:      int(e.GetBannerId()),
+			SlotID:        int(e.GetSlotId()),
+			SosialGroupID: int(e.GetGroupId()),
+			ExperimentID:  int(e.GetExperimentId()),
+			Arm:           e.GetArm(),
+			ImpressionID:  e.GetImpressionId(),
+			IP:            e.GetIp(),
+			UserAgent:     e.GetUserAgent(),
+		}
+		if e.GetTime() != nil {
+			event.At = e.GetTime().AsTime()
+		}
+		events = append(events, event)
+	}
+
+	if err := s.app.RecordEvents(ctx, events); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &pb.RecordEventsResponse{}, nil
+}
+
+func (s *service) TrackConversion(ctx context.Context, req *pb.TrackConversionRequest,
+) (*pb.TrackConversionResponse, error) {
+	conv := storage.Conversion{
+		ImpressionID: req.GetImpressionId(),
+		Value:        req.GetValue(),
+	}
+
+	if err := s.app.TrackConversion(ctx, conv); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &pb.TrackConversionResponse{}, nil
+}
+
+func (s *service) GetStats(ctx context.Context, req *pb.GetStatsRequest) (*pb.GetStatsResponse, error) {
+	bannerIDs := toInts(req.GetBannerIds())
+
+	stats, err := s.app.GetBannersStat(ctx, int(req.GetSlotId()), int(req.GetGroupId()), bannerIDs)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	resp := &pb.GetStatsResponse{Stats: make([]*pb.Statistic, 0, len(stats))}
+	for _, stat := range stats {
+		resp.Stats = append(resp.Stats, &pb.Statistic{
+			BannerId:        int64(stat.BannerID),
+			SlotId:          int64(stat.SlotID),
+			GroupId:         int64(stat.SosialGroupID),
+			Clicks:          int64(stat.ClicksCount),
+			Shows:           int64(stat.ShowsCount),
+			Conversions:     int64(stat.Conversions),
+			ConversionValue: stat.ConversionValue,
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *service) CreateExperiment(ctx context.Context, req *pb.CreateExperimentRequest) (*pb.Experiment, error) {
+	exp := storage.Experiment{
+		SlotID: int(req.GetSlotId()),
+		Descr:  req.GetDescr(),
+		Arms:   make([]storage.ExperimentArm, 0, len(req.GetArms())),
+	}
+	for _, arm := range req.GetArms() {
+		exp.Arms = append(exp.Arms, storage.ExperimentArm{
+			Name:     arm.GetName(),
+			Strategy: arm.GetStrategy(),
+			Weight:   int(arm.GetWeight()),
+		})
+	}
+
+	id, err := s.app.CreateExperiment(ctx, exp)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &pb.Experiment{
+		Id:     int64(id),
+		SlotId: req.GetSlotId(),
+		Descr:  req.GetDescr(),
+		Active: true,
+		Arms:   req.GetArms(),
+	}, nil
+}
+
+func (s *service) GetExperimentReport(ctx context.Context, req *pb.GetExperimentReportRequest,
+) (*pb.GetExperimentReportResponse, error) {
+	reports, err := s.app.GetExperimentReport(ctx, int(req.GetExperimentId()))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	resp := &pb.GetExperimentReportResponse{Arms: make([]*pb.ArmReport, 0, len(reports))}
+	for _, report := range reports {
+		resp.Arms = append(resp.Arms, &pb.ArmReport{
+			Arm:      report.Arm,
+			Strategy: report.Strategy,
+			Shows:    int64(report.ShowsCount),
+			Clicks:   int64(report.ClicksCount),
+			Ctr:      report.CTR,
+			CiLow:    report.CILow,
+			CiHigh:   report.CIHigh,
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *service) GetRevenueReport(ctx context.Context, req *pb.GetRevenueReportRequest,
+) (*pb.GetRevenueReportResponse, error) {
+	reports, err := s.app.GetRevenueReport(ctx, int(req.GetSlotId()))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	resp := &pb.GetRevenueReportResponse{Slots: make([]*pb.SlotRevenue, 0, len(reports))}
+	for _, report := range reports {
+		slot := &pb.SlotRevenue{
+			SlotId:  int64(report.SlotID),
+			Revenue: report.Revenue,
+			Banners: make([]*pb.BannerRevenue, 0, len(report.Banners)),
+		}
+		for _, banner := range report.Banners {
+			slot.Banners = append(slot.Banners, &pb.BannerRevenue{
+				BannerId:    int64(banner.BannerID),
+				Pricing:     banner.Pricing,
+				Bid:         banner.Bid,
+				Shows:       int64(banner.ShowsCount),
+				Clicks:      int64(banner.ClicksCount),
+				Conversions: int64(banner.Conversions),
+				Revenue:     banner.Revenue,
+			})
+		}
+		resp.Slots = append(resp.Slots, slot)
+	}
+
+	return resp, nil
+}
+
+func toPbBanner(banner storage.Banner) *pb.Banner {
+	return &pb.Banner{
+		Id:      int64(banner.ID),
+		Descr:   banner.Descr,
+		Pricing: banner.Pricing,
+		Bid:     banner.Bid,
+	}
+}
+
+// callClient describes the caller for the fraud filters.
+func callClient(ctx context.Context) storage.ClientInfo {
+	client := storage.ClientInfo{IP: peerIP(ctx)}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if userAgents := md.Get("user-agent"); len(userAgents) > 0 {
+		client.UserAgent = userAgents[0]
+	}
+
+	return client
+}
+
+func fromPbAttributes(a *pb.Attributes) segment.Attributes {
+	return segment.Attributes{
+		Age:         int(a.GetAge()),
+		Gender:      a.GetGender(),
+		Device:      a.GetDevice(),
+		Country:     a.GetCountry(),
+		Region:      a.GetRegion(),
+		OS:          a.GetOs(),
+		Language:    a.GetLanguage(),
+		Referrer:    a.GetReferrer(),
+		UTMSource:   a.GetUtmSource(),
+		UTMMedium:   a.GetUtmMedium(),
+		UTMCampaign: a.GetUtmCampaign(),
+	}
+}
+
+func toInts(values []int64) []int {
+	result := make([]int, 0, len(values))
+	for _, v := range values {
+		result = append(result, int(v))
+	}
+	return result
+}
+
+func toInt64s(values []int) []int64 {
+	result := make([]int64, 0, len(values))
+	for _, v := range values {
+		result = append(result, int64(v))
+	}
+	return result
+}
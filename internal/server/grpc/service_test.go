@@ -0,0 +1,141 @@
+package internalgrpc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/app"
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/segment"
+	"github.com/otus-murashko/banners-rotation/internal/server/grpc/pb"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// serviceApp records the arguments the service calls the application with
+// and fails every call with err when it is set.
+type serviceApp struct {
+	app.Application
+	err      error
+	banner   storage.Banner
+	rotation storage.Rotation
+	slotID   int
+	groupID  int
+	attrs    segment.Attributes
+}
+
+func (a *serviceApp) CreateBanner(_ context.Context, banner storage.Banner) (int, error) {
+	a.banner = banner
+	return 5, a.err
+}
+
+func (a *serviceApp) AddBannerToSlot(_ context.Context, rotation storage.Rotation) error {
+	a.rotation = rotation
+	return a.err
+}
+
+func (a *serviceApp) GetBannerRotation(_ context.Context, slotID, sGroupID int, attrs segment.Attributes,
+) (storage.BannerRotation, error) {
+	a.slotID, a.groupID, a.attrs = slotID, sGroupID, attrs
+	rotation := storage.BannerRotation{
+		Banner:       storage.Banner{ID: 7, Descr: "banner", Pricing: storage.PricingCPC, Bid: 0.5},
+		ExperimentID: 2,
+		Arm:          "ucb1",
+		ImpressionID: 11,
+	}
+	return rotation, a.err
+}
+
+func TestServiceCreateBanner(t *testing.T) {
+	a := &serviceApp{}
+	s := &service{app: a}
+
+	got, err := s.CreateBanner(context.Background(), &pb.CreateBannerRequest{Descr: "banner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (storage.Banner{Descr: "banner"}); a.banner != want {
+		t.Errorf("created banner = %+v, want %+v", a.banner, want)
+	}
+	want := &pb.Banner{Id: 5, Descr: "banner", Pricing: storage.PricingCPM}
+	if !proto.Equal(got, want) {
+		t.Errorf("response = %v, want %v", got, want)
+	}
+}
+
+func TestServiceAddBannerToSlot(t *testing.T) {
+	a := &serviceApp{}
+	s := &service{app: a}
+
+	req := &pb.AddBannerToSlotRequest{
+		BannerId: 7,
+		SlotId:   3,
+		Targeting: &pb.Targeting{
+			Countries: []string{"RU"},
+			Devices:   []string{"mobile"},
+			Languages: []string{"ru"},
+		},
+	}
+	if _, err := s.AddBannerToSlot(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	want := storage.Rotation{
+		BannerID: 7,
+		SlotID:   3,
+		Targeting: storage.Targeting{
+			Countries: []string{"RU"},
+			Devices:   []string{"mobile"},
+			Languages: []string{"ru"},
+		},
+	}
+	if !reflect.DeepEqual(a.rotation, want) {
+		t.Errorf("rotation = %+v, want %+v", a.rotation, want)
+	}
+}
+
+func TestServiceGetBanner(t *testing.T) {
+	a := &serviceApp{}
+	s := &service{app: a}
+
+	req := &pb.GetBannerRequest{
+		SlotId:     3,
+		GroupId:    4,
+		Attributes: &pb.Attributes{Age: 30, Country: "RU", Device: "mobile"},
+	}
+	got, err := s.GetBanner(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.slotID != 3 || a.groupID != 4 {
+		t.Errorf("slot, group = %d, %d, want 3, 4", a.slotID, a.groupID)
+	}
+	if want := (segment.Attributes{Age: 30, Country: "RU", Device: "mobile"}); a.attrs != want {
+		t.Errorf("attributes = %+v, want %+v", a.attrs, want)
+	}
+	want := &pb.GetBannerResponse{
+		Banner:       &pb.Banner{Id: 7, Descr: "banner", Pricing: storage.PricingCPC, Bid: 0.5},
+		ExperimentId: 2,
+		Arm:          "ucb1",
+		ImpressionId: 11,
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("response = %v, want %v", got, want)
+	}
+}
+
+func TestServiceErrors(t *testing.T) {
+	s := &service{app: &serviceApp{err: apperror.NotFound("slot 3 not found")}}
+
+	_, err := s.GetBanner(context.Background(), &pb.GetBannerRequest{SlotId: 3})
+
+	st, _ := status.FromError(err)
+	if st.Code() != codes.NotFound || st.Message() != "slot 3 not found" {
+		t.Errorf("status = %v %q, want %v %q", st.Code(), st.Message(), codes.NotFound, "slot 3 not found")
+	}
+}
@@ -0,0 +1,71 @@
+package internalhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/app"
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/auth"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+// authApp knows a key of the read scope. The other methods of the application
+// are not used by the middleware.
+type authApp struct {
+	app.Application
+}
+
+func (authApp) Authenticate(_ context.Context, key string, scope auth.Scope) (storage.APIKey, error) {
+	switch {
+	case key == "":
+		return storage.APIKey{}, apperror.Unauthenticated("API key is required")
+	case key != "br_read":
+		return storage.APIKey{}, apperror.Unauthenticated("API key is invalid or revoked")
+	case scope != auth.ScopeRead:
+		return storage.APIKey{}, apperror.PermissionDenied("API key has no %s scope", scope)
+	}
+	return storage.APIKey{ID: 1, Tenant: "acme", Scopes: []string{"read"}}, nil
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		scope      auth.Scope
+		wantStatus int
+	}{
+		{name: "missing key", scope: auth.ScopeRead, wantStatus: http.StatusUnauthorized},
+		{name: "unknown key", header: "X-API-Key", value: "br_unknown", scope: auth.ScopeRead, wantStatus: http.StatusUnauthorized},
+		{name: "bearer without token", header: "Authorization", value: "Bearer ", scope: auth.ScopeRead, wantStatus: http.StatusUnauthorized},
+		{name: "scope denied", header: "X-API-Key", value: "br_read", scope: auth.ScopeAdmin, wantStatus: http.StatusForbidden},
+		{name: "key header", header: "X-API-Key", value: "br_read", scope: auth.ScopeRead, wantStatus: http.StatusOK},
+		{name: "bearer token", header: "Authorization", value: "Bearer br_read", scope: auth.ScopeRead, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotKey storage.APIKey
+			handler := authMiddleware(authApp{}, tt.scope, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotKey, _ = auth.KeyFrom(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/v1/slots/1/banners", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && gotKey.ID != 1 {
+				t.Errorf("handler key = %+v, want the key of the request", gotKey)
+			}
+		})
+	}
+}
@@ -57,3 +57,46 @@ func (h Handler) statHandler(w http.ResponseWriter, r *http.Request) {
 		handleNotExpecterRequest(w)
 	}
 }
+
+func (h Handler) experimentHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		addExperiment(w, r, h.app)
+	default:
+		handleNotExpecterRequest(w)
+	}
+}
+
+func (h Handler) experimentStatHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		getExperimentReport(w, r, h.app)
+	default:
+		handleNotExpecterRequest(w)
+	}
+}
+
+func (h Handler) conversionHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		trackConversion(w, r, h.app)
+	default:
+		handleNotExpecterRequest(w)
+	}
+}
+
+func (h Handler) revenueStatHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		getRevenueReport(w, r, h.app)
+	default:
+		handleNotExpecterRequest(w)
+	}
+}
+
+// with adapts a helper to the handler signature of the /v1 routes.
+func (h Handler) with(helper func(w http.ResponseWriter, r *http.Request, a app.Application)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		helper(w, r, h.app)
+	}
+}
@@ -0,0 +1,57 @@
+package internalhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/app"
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/config"
+)
+
+// readyApp reports the readiness error err.
+type readyApp struct {
+	app.Application
+	err error
+}
+
+func (a readyApp) Ready(context.Context) error { return a.err }
+
+func TestHealth(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		readyErr   error
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "alive", path: "/healthz", readyErr: apperror.Unavailable("database is unavailable"), wantStatus: http.StatusOK, wantBody: `"ok"`},
+		{name: "ready", path: "/readyz", wantStatus: http.StatusOK, wantBody: `"ok"`},
+		{
+			name:       "not ready",
+			path:       "/readyz",
+			readyErr:   apperror.Unavailable("database is unavailable"),
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "database is unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestHandler(t, readyApp{err: tt.readyErr}, config.Server{})
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", w.Body, tt.wantBody)
+			}
+		})
+	}
+}
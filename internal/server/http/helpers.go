@@ -1,250 +1,443 @@
 package internalhttp
 
 import (
-	"context"
-	"encoding/json"
-	"io"
 	"net/http"
-	"strconv"
+	"strings"
 
 	"github.com/otus-murashko/banners-rotation/internal/app"
+	"github.com/otus-murashko/banners-rotation/internal/segment"
 	"github.com/otus-murashko/banners-rotation/internal/storage"
 )
 
 func getBannerRotation(w http.ResponseWriter, r *http.Request, a app.Application) {
 
-	slotID, err := strconv.Atoi(r.URL.Query().Get("slot_id"))
+	slotID, err := queryInt(r, "slot_id", true)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	selectBanner(w, r, a, slotID)
+}
 
+func getSlotBanner(w http.ResponseWriter, r *http.Request, a app.Application) {
+
+	slotID, err := pathInt(r, "id")
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
 
-	groupID, err := strconv.Atoi(r.URL.Query().Get("group_id"))
+	selectBanner(w, r, a, slotID)
+}
+
+func selectBanner(w http.ResponseWriter, r *http.Request, a app.Application, slotID int) {
+
+	// group_id is optional, the group is resolved from the attributes otherwise
+	groupID, err := queryInt(r, "group_id", false)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
 
-	banner, err := a.GetBannerRotation(context.Background(), slotID, groupID)
+	attrs, err := getRequestAttributes(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
 
+	banner, err := a.GetBannerRotation(r.Context(), slotID, groupID, attrs)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
 
-	data, err := json.Marshal(banner)
+	writeJSON(w, http.StatusOK, banner)
+}
+
+func getSlotsBanners(w http.ResponseWriter, r *http.Request, a app.Application) {
+
+	var batch storage.RotationBatch
+	if err := readJSON(w, r, &batch); err != nil {
+		writeError(w, err)
+		return
+	}
 
+	attrs, err := getRequestAttributes(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write(data)
+	rotations, err := a.GetBannerRotations(r.Context(), batch.SlotIDs, batch.GroupID, attrs, batch.Unique)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// keep the order of the request, slots without banners are left out
+	slots := make([]storage.SlotRotation, 0, len(rotations))
+	for _, slotID := range batch.SlotIDs {
+		if rotation, ok := rotations[slotID]; ok {
+			slots = append(slots, storage.SlotRotation{SlotID: slotID, BannerRotation: rotation})
+		}
+	}
 
+	writeJSON(w, http.StatusOK, slots)
 }
 
 func addBannerRotation(w http.ResponseWriter, r *http.Request, a app.Application) {
 
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+	var rotation storage.Rotation
+	if err := readJSON(w, r, &rotation); err != nil {
+		writeError(w, err)
 		return
 	}
 
-	var rotation storage.Rotation
-	err = json.Unmarshal(body, &rotation)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+	if err := a.AddBannerToSlot(r.Context(), rotation); err != nil {
+		writeError(w, err)
 		return
 	}
 
-	err = a.AddBannerToSlot(context.Background(), rotation.BannerID, rotation.SlotID)
+	w.WriteHeader(http.StatusOK)
+}
+
+func putSlotBanner(w http.ResponseWriter, r *http.Request, a app.Application) {
 
+	rotation, err := pathRotation(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	// the targeting is optional
+	if r.ContentLength != 0 {
+		if err = readJSON(w, r, &rotation.Targeting); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	if err = a.AddBannerToSlot(r.Context(), rotation); err != nil {
+		writeError(w, err)
+		return
+	}
 
+	w.WriteHeader(http.StatusOK)
 }
 
-func deleteBannerRotation(w http.ResponseWriter, r *http.Request, a app.Application) {
+func deleteSlotBanner(w http.ResponseWriter, r *http.Request, a app.Application) {
 
-	body, err := io.ReadAll(r.Body)
+	rotation, err := pathRotation(r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
 
-	var rotation storage.Rotation
-	err = json.Unmarshal(body, &rotation)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+	if err = a.DeleteBannerFromSlot(r.Context(), rotation.BannerID, rotation.SlotID); err != nil {
+		writeError(w, err)
 		return
 	}
 
-	err = a.DeleteBannerFromSlot(context.Background(), rotation.BannerID, rotation.SlotID)
+	w.WriteHeader(http.StatusNoContent)
+}
 
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+func deleteBannerRotation(w http.ResponseWriter, r *http.Request, a app.Application) {
+
+	var rotation storage.Rotation
+	if err := readJSON(w, r, &rotation); err != nil {
+		writeError(w, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	if err := a.DeleteBannerFromSlot(r.Context(), rotation.BannerID, rotation.SlotID); err != nil {
+		writeError(w, err)
+		return
+	}
 
+	w.WriteHeader(http.StatusOK)
 }
 
 func addBanner(w http.ResponseWriter, r *http.Request, a app.Application) {
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+
+	var banner storage.Banner
+	if err := readJSON(w, r, &banner); err != nil {
+		writeError(w, err)
 		return
 	}
 
-	var banner storage.Banner
-	err = json.Unmarshal(body, &banner)
+	id, err := a.CreateBanner(r.Context(), banner)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
+	banner.ID = id
+	if banner.Pricing == "" {
+		banner.Pricing = storage.PricingCPM
+	}
 
-	id, err := a.CreateBanner(context.Background(), banner.Descr)
+	writeJSON(w, http.StatusOK, banner)
+}
+
+func addSlot(w http.ResponseWriter, r *http.Request, a app.Application) {
 
+	var slot storage.Slot
+	if err := readJSON(w, r, &slot); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	id, err := a.CreateSlot(r.Context(), slot.Descr)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
-	banner.ID = id
+	slot.ID = id
+
+	writeJSON(w, http.StatusOK, slot)
+}
 
-	data, err := json.Marshal(banner)
+func updateClickStat(w http.ResponseWriter, r *http.Request, a app.Application) {
 
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+	var stat storage.Statistic
+	if err := readJSON(w, r, &stat); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := a.UpdateClickStat(r.Context(), stat, requestClient(r)); err != nil {
+		writeError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	w.Write(data)
 }
 
-func addSlot(w http.ResponseWriter, r *http.Request, a app.Application) {
-	body, err := io.ReadAll(r.Body)
+func addSlotBannerClick(w http.ResponseWriter, r *http.Request, a app.Application) {
+
+	rotation, err := pathRotation(r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
 
-	var slot storage.Slot
-	err = json.Unmarshal(body, &slot)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+	var stat storage.Statistic
+	if err = readJSON(w, r, &stat); err != nil {
+		writeError(w, err)
 		return
 	}
+	stat.BannerID, stat.SlotID = rotation.BannerID, rotation.SlotID
 
-	id, err := a.CreateSlot(context.Background(), slot.Descr)
+	if err = a.UpdateClickStat(r.Context(), stat, requestClient(r)); err != nil {
+		writeError(w, err)
+		return
+	}
 
+	w.WriteHeader(http.StatusOK)
+}
+
+func recordEvents(w http.ResponseWriter, r *http.Request, a app.Application) {
+
+	events, err := readEvents(w, r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
-	slot.ID = id
 
-	data, err := json.Marshal(slot)
+	if err = a.RecordEvents(r.Context(), events); err != nil {
+		writeError(w, err)
+		return
+	}
 
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+	w.WriteHeader(http.StatusOK)
+}
+
+func trackConversion(w http.ResponseWriter, r *http.Request, a app.Application) {
+
+	var conv storage.Conversion
+	if err := readJSON(w, r, &conv); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := a.TrackConversion(r.Context(), conv); err != nil {
+		writeError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	w.Write(data)
 }
 
-func updateClickStat(w http.ResponseWriter, r *http.Request, a app.Application) {
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+func addGroup(w http.ResponseWriter, r *http.Request, a app.Application) {
+
+	var group storage.SosialGroup
+	if err := readJSON(w, r, &group); err != nil {
+		writeError(w, err)
 		return
 	}
 
-	var stat storage.Statistic
-	err = json.Unmarshal(body, &stat)
+	id, err := a.CreateGroup(r.Context(), group.Descr)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
+	group.ID = id
+
+	writeJSON(w, http.StatusOK, group)
+}
+
+func addExperiment(w http.ResponseWriter, r *http.Request, a app.Application) {
 
-	err = a.UpdateClickStat(context.Background(), stat)
+	var exp storage.Experiment
+	if err := readJSON(w, r, &exp); err != nil {
+		writeError(w, err)
+		return
+	}
 
+	id, err := a.CreateExperiment(r.Context(), exp)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
+	exp.ID = id
+	exp.Active = true
 
-	w.WriteHeader(http.StatusOK)
+	writeJSON(w, http.StatusOK, exp)
 }
 
-func addGroup(w http.ResponseWriter, r *http.Request, a app.Application) {
-	body, err := io.ReadAll(r.Body)
+func getExperimentReport(w http.ResponseWriter, r *http.Request, a app.Application) {
+
+	experimentID, err := queryInt(r, "experiment_id", true)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
 
-	var group storage.SosialGroup
-	err = json.Unmarshal(body, &group)
+	writeExperimentReport(w, r, a, experimentID)
+}
+
+func getExperimentReportByID(w http.ResponseWriter, r *http.Request, a app.Application) {
+
+	experimentID, err := pathInt(r, "id")
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
 
-	id, err := a.CreateGroup(context.Background(), group.Descr)
+	writeExperimentReport(w, r, a, experimentID)
+}
 
+func writeExperimentReport(w http.ResponseWriter, r *http.Request, a app.Application, experimentID int) {
+
+	report, err := a.GetExperimentReport(r.Context(), experimentID)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
-	group.ID = id
 
-	data, err := json.Marshal(group)
+	writeJSON(w, http.StatusOK, report)
+}
+
+func getRevenueReport(w http.ResponseWriter, r *http.Request, a app.Application) {
 
+	// slot_id is optional, all slots are reported otherwise
+	slotID, err := queryInt(r, "slot_id", false)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write(data)
+	report, err := a.GetRevenueReport(r.Context(), slotID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+func pathRotation(r *http.Request) (storage.Rotation, error) {
+	slotID, err := pathInt(r, "id")
+	if err != nil {
+		return storage.Rotation{}, err
+	}
+
+	bannerID, err := pathInt(r, "bannerID")
+	if err != nil {
+		return storage.Rotation{}, err
+	}
+
+	return storage.Rotation{BannerID: bannerID, SlotID: slotID}, nil
+}
+
+func requestClient(r *http.Request) storage.ClientInfo {
+	return storage.ClientInfo{IP: clientIP(r), UserAgent: r.UserAgent()}
+}
+
+func getRequestAttributes(r *http.Request) (segment.Attributes, error) {
+	query := r.URL.Query()
+
+	attrs := segment.Attributes{
+		Gender:      query.Get("gender"),
+		Device:      query.Get("device"),
+		Country:     query.Get("country"),
+		Region:      query.Get("region"),
+		OS:          query.Get("os"),
+		Language:    query.Get("language"),
+		Referrer:    query.Get("referrer"),
+		UTMSource:   query.Get("utm_source"),
+		UTMMedium:   query.Get("utm_medium"),
+		UTMCampaign: query.Get("utm_campaign"),
+	}
+
+	if attrs.Referrer == "" {
+		attrs.Referrer = r.Referer()
+	}
+
+	if attrs.Language == "" {
+		attrs.Language = primaryLanguage(r.Header.Get("Accept-Language"))
+	}
+
+	age, err := queryInt(r, "age", false)
+	if err != nil {
+		return segment.Attributes{}, err
+	}
+	attrs.Age = age
+
+	return attrs, nil
+}
+
+// primaryLanguage returns the first language tag of an Accept-Language header.
+func primaryLanguage(header string) string {
+	lang, _, _ := strings.Cut(header, ",")
+	lang, _, _ = strings.Cut(lang, ";")
+
+	return strings.TrimSpace(lang)
 }
 
 func handleNotExpecterRequest(w http.ResponseWriter) {
-	w.WriteHeader(http.StatusNotImplemented)
+	writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: errorBody{
+		Code:    "method_not_allowed",
+		Message: "method is not allowed",
+	}})
+}
+
+type healthResponse struct {
+	Status string `json:"status"`
+}
+
+// getHealth reports that the process serves requests, the dependencies are
+// checked by getReadiness only, so an outage of the database does not get the
+// service restarted.
+func getHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+func getReadiness(w http.ResponseWriter, r *http.Request, a app.Application) {
+	if err := a.Ready(r.Context()); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, healthResponse{Status: "ok"})
 }
@@ -0,0 +1,150 @@
+package internalhttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/app"
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/auth"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// defaultIdempotencyWindow is how long the responses are replayed when
+	// the window is not configured.
+	defaultIdempotencyWindow = 24 * time.Hour
+	// defaultIdempotencyLease is how long a request without a timeout holds
+	// its key before a retry may take it over.
+	defaultIdempotencyLease = time.Minute
+)
+
+// responseRecorder keeps a copy of the response written to the client.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(code int) {
+	rec.status = code
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+func (rec *responseRecorder) Write(data []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(data)
+	return rec.ResponseWriter.Write(data)
+}
+
+// idempotencyMiddleware replays the stored response for a repeated
+// Idempotency-Key of a write request. Reusing a key for a different request
+// is rejected, as is a retry while the first request is still running. The
+// first request holds the key for the lease only, so a retry is not blocked
+// by a request that died before storing its response. Server errors are not
+// stored, so the request can be retried with the same key.
+func idempotencyMiddleware(a app.Application, window, lease time.Duration, next http.Handler) http.Handler {
+	if window <= 0 {
+		window = defaultIdempotencyWindow
+	}
+	if lease <= 0 {
+		lease = defaultIdempotencyLease
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// keys of different clients must not collide
+		if apiKey, ok := auth.KeyFrom(r.Context()); ok {
+			key = fmt.Sprintf("%d:%s", apiKey.ID, key)
+		}
+
+		// the handler applies its own, possibly lower, limit to the copy
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxEventsBodySize))
+		if err != nil {
+			writeError(w, bodyError(err))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := requestFingerprint(r, body)
+
+		stored, claimed, err := a.ClaimIdempotencyKey(r.Context(), key, fingerprint, window, lease)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		if !claimed {
+			replayResponse(w, stored, fingerprint)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		// the key must be settled even when the request context is done
+		ctx := context.WithoutCancel(r.Context())
+
+		if rec.status == 0 || rec.status >= http.StatusInternalServerError {
+			if err := a.ReleaseIdempotencyKey(ctx, key); err != nil {
+				slog.ErrorContext(ctx, "failed to release idempotency key", "error", err)
+			}
+			return
+		}
+
+		resp := storage.IdempotentResponse{
+			Status:      rec.status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		}
+		if err := a.SaveIdempotentResponse(ctx, key, resp); err != nil {
+			slog.ErrorContext(ctx, "failed to save idempotent response", "error", err)
+		}
+	})
+}
+
+func replayResponse(w http.ResponseWriter, stored storage.IdempotentResponse, fingerprint string) {
+	if stored.Fingerprint != fingerprint {
+		writeError(w, apperror.Validation("%s is already used for a different request", idempotencyKeyHeader))
+		return
+	}
+
+	if stored.Status == 0 {
+		writeError(w, apperror.Conflict("request with the same %s is in progress", idempotencyKeyHeader))
+		return
+	}
+
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
+
+// requestFingerprint identifies the request by its method, URL and body.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,221 @@
+package internalhttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/app"
+	"github.com/otus-murashko/banners-rotation/internal/auth"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+// idempotencyApp keeps the idempotency keys in memory. The other methods of
+// the application are not used by the middleware.
+type idempotencyApp struct {
+	app.Application
+	keys          map[string]storage.IdempotentResponse
+	window, lease time.Duration
+}
+
+func newIdempotencyApp() *idempotencyApp {
+	return &idempotencyApp{keys: make(map[string]storage.IdempotentResponse)}
+}
+
+func (a *idempotencyApp) ClaimIdempotencyKey(_ context.Context, key, fingerprint string,
+	window, lease time.Duration,
+) (storage.IdempotentResponse, bool, error) {
+	a.window, a.lease = window, lease
+
+	if stored, ok := a.keys[key]; ok {
+		return stored, false, nil
+	}
+	a.keys[key] = storage.IdempotentResponse{Fingerprint: fingerprint}
+	return storage.IdempotentResponse{}, true, nil
+}
+
+func (a *idempotencyApp) SaveIdempotentResponse(_ context.Context, key string, resp storage.IdempotentResponse) error {
+	resp.Fingerprint = a.keys[key].Fingerprint
+	a.keys[key] = resp
+	return nil
+}
+
+func (a *idempotencyApp) ReleaseIdempotencyKey(_ context.Context, key string) error {
+	delete(a.keys, key)
+	return nil
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	type request struct {
+		method, key, body string
+		wantStatus        int
+		wantBody          string
+		wantReplayed      bool
+	}
+
+	tests := []struct {
+		name      string
+		status    int
+		requests  []request
+		wantCalls int
+	}{
+		{
+			name:   "without a key",
+			status: http.StatusOK,
+			requests: []request{
+				{method: http.MethodPost, body: "a", wantStatus: http.StatusOK, wantBody: "a"},
+				{method: http.MethodPost, body: "a", wantStatus: http.StatusOK, wantBody: "a"},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:   "read request",
+			status: http.StatusOK,
+			requests: []request{
+				{method: http.MethodGet, key: "k", wantStatus: http.StatusOK},
+				{method: http.MethodGet, key: "k", wantStatus: http.StatusOK},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:   "repeated request is replayed",
+			status: http.StatusCreated,
+			requests: []request{
+				{method: http.MethodPost, key: "k", body: "a", wantStatus: http.StatusCreated, wantBody: "a"},
+				{method: http.MethodPost, key: "k", body: "a", wantStatus: http.StatusCreated, wantBody: "a", wantReplayed: true},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:   "key of a different request",
+			status: http.StatusOK,
+			requests: []request{
+				{method: http.MethodPost, key: "k", body: "a", wantStatus: http.StatusOK, wantBody: "a"},
+				{method: http.MethodPost, key: "k", body: "b", wantStatus: http.StatusBadRequest},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:   "client errors are replayed",
+			status: http.StatusNotFound,
+			requests: []request{
+				{method: http.MethodDelete, key: "k", wantStatus: http.StatusNotFound},
+				{method: http.MethodDelete, key: "k", wantStatus: http.StatusNotFound, wantReplayed: true},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:   "server errors are retried",
+			status: http.StatusInternalServerError,
+			requests: []request{
+				{method: http.MethodPost, key: "k", body: "a", wantStatus: http.StatusInternalServerError, wantBody: "a"},
+				{method: http.MethodPost, key: "k", body: "a", wantStatus: http.StatusInternalServerError, wantBody: "a"},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:   "body over the limit",
+			status: http.StatusOK,
+			requests: []request{
+				{method: http.MethodPost, key: "k", body: strings.Repeat("a", maxEventsBodySize+1), wantStatus: http.StatusBadRequest},
+			},
+			wantCalls: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				body, _ := io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+				w.Write(body)
+			})
+			mw := idempotencyMiddleware(newIdempotencyApp(), time.Hour, time.Minute, handler)
+
+			for i, req := range tt.requests {
+				r := httptest.NewRequest(req.method, "/v1/banners", strings.NewReader(req.body))
+				if req.key != "" {
+					r.Header.Set(idempotencyKeyHeader, req.key)
+				}
+				rec := httptest.NewRecorder()
+				mw.ServeHTTP(rec, r)
+
+				if rec.Code != req.wantStatus {
+					t.Errorf("request %d: status = %d, want %d", i, rec.Code, req.wantStatus)
+				}
+				if req.wantBody != "" && rec.Body.String() != req.wantBody {
+					t.Errorf("request %d: body = %q, want %q", i, rec.Body.String(), req.wantBody)
+				}
+				if replayed := rec.Header().Get("Idempotent-Replayed") == "true"; replayed != req.wantReplayed {
+					t.Errorf("request %d: replayed = %v, want %v", i, replayed, req.wantReplayed)
+				}
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("handler called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddlewareInProgress(t *testing.T) {
+	a := newIdempotencyApp()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a retry arrives while the first request is running
+		retry := httptest.NewRequest(http.MethodPost, "/v1/banners", strings.NewReader("a"))
+		retry.Header.Set(idempotencyKeyHeader, "k")
+		rec := httptest.NewRecorder()
+		idempotencyMiddleware(a, 0, 0, http.NotFoundHandler()).ServeHTTP(rec, retry)
+
+		if rec.Code != http.StatusConflict {
+			t.Errorf("retry status = %d, want %d", rec.Code, http.StatusConflict)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/banners", strings.NewReader("a"))
+	r.Header.Set(idempotencyKeyHeader, "k")
+	idempotencyMiddleware(a, 0, 0, handler).ServeHTTP(httptest.NewRecorder(), r)
+
+	if a.window != defaultIdempotencyWindow || a.lease != defaultIdempotencyLease {
+		t.Errorf("window, lease = %s, %s, want the defaults %s, %s",
+			a.window, a.lease, defaultIdempotencyWindow, defaultIdempotencyLease)
+	}
+}
+
+func TestIdempotencyKeysOfClients(t *testing.T) {
+	tests := []struct {
+		name    string
+		apiKey  *storage.APIKey
+		wantKey string
+	}{
+		{name: "unauthenticated", wantKey: "k"},
+		{name: "API key", apiKey: &storage.APIKey{ID: 7}, wantKey: "7:k"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newIdempotencyApp()
+			mw := idempotencyMiddleware(a, time.Hour, time.Minute, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			r := httptest.NewRequest(http.MethodPost, "/v1/banners", strings.NewReader("a"))
+			r.Header.Set(idempotencyKeyHeader, "k")
+			if tt.apiKey != nil {
+				r = r.WithContext(auth.WithKey(r.Context(), *tt.apiKey))
+			}
+			mw.ServeHTTP(httptest.NewRecorder(), r)
+
+			if _, ok := a.keys[tt.wantKey]; !ok || len(a.keys) != 1 {
+				t.Errorf("keys = %v, want only %q", a.keys, tt.wantKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package internalhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/config"
+	"github.com/otus-murashko/banners-rotation/internal/metrics"
+)
+
+// routeRequests returns the requests counted for the route with the status.
+func routeRequests(t *testing.T, route, status string) float64 {
+	t.Helper()
+
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "banners_rotation_http_requests_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["route"] == route && labels["status"] == status {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestRequestMetrics(t *testing.T) {
+	const route = "PUT /v1/slots/{id}/banners/{bannerID}"
+	handler := newTestHandler(t, &rotationApp{}, config.Server{})
+
+	ok := routeRequests(t, route, "200")
+	invalid := routeRequests(t, route, "400")
+
+	for _, path := range []string{"/v1/slots/3/banners/7", "/v1/slots/4/banners/8", "/v1/slots/x/banners/8"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, path, nil))
+	}
+
+	if got := routeRequests(t, route, "200") - ok; got != 2 {
+		t.Errorf("requests with status 200 = %v, want 2", got)
+	}
+	if got := routeRequests(t, route, "400") - invalid; got != 1 {
+		t.Errorf("requests with status 400 = %v, want 1", got)
+	}
+}
@@ -1,14 +1,35 @@
 package internalhttp
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/app"
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/auth"
+	"github.com/otus-murashko/banners-rotation/internal/logger"
+	"github.com/otus-murashko/banners-rotation/internal/metrics"
+	"github.com/otus-murashko/banners-rotation/internal/ratelimit"
+	"github.com/otus-murashko/banners-rotation/internal/tenant"
+	"github.com/otus-murashko/banners-rotation/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type statusWriter struct {
 	http.ResponseWriter
 	status int
+	// err is the error the response was written for
+	err error
 }
 
 func (handler *statusWriter) WriteHeader(code int) {
@@ -16,13 +37,183 @@ func (handler *statusWriter) WriteHeader(code int) {
 	handler.ResponseWriter.WriteHeader(code)
 }
 
-func loggingMiddleware(next http.Handler) http.Handler {
+func (handler *statusWriter) Unwrap() http.ResponseWriter {
+	return handler.ResponseWriter
+}
+
+// recordError passes err to the statusWriters under w, so the request is
+// logged and traced with it.
+func recordError(w http.ResponseWriter, err error) {
+	for {
+		if handler, ok := w.(*statusWriter); ok {
+			handler.err = err
+		}
+
+		wrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		w = wrapper.Unwrap()
+	}
+}
+
+// requestIDMiddleware takes the request ID sent by the client or generates
+// one, returns it in the response and passes it on in the request context.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(logger.RequestIDHeader)
+		if !logger.ValidRequestID(id) {
+			id = logger.NewRequestID()
+		}
+
+		w.Header().Set(logger.RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logger.WithRequestID(r.Context(), id)))
+	})
+}
+
+// tracingMiddleware continues the trace of the caller sent in the traceparent
+// header or starts a new one and spans the request.
+func tracingMiddleware(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Start(ctx, route, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("user_agent.original", r.UserAgent()),
+			))
+
+		httpWriter := statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(&httpWriter, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", httpWriter.status))
+		if httpWriter.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(httpWriter.status))
+			tracing.End(span, httpWriter.err)
+			return
+		}
+		span.End()
+	})
+}
+
+// loggingMiddleware logs the request and records its metrics under the route
+// pattern, so path parameters do not split the series. Server errors are
+// logged with their cause.
+func loggingMiddleware(route string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		startTime := time.Now()
-		httpWriter := statusWriter{w, 200}
+		httpWriter := statusWriter{ResponseWriter: w, status: http.StatusOK}
 		next.ServeHTTP(&httpWriter, r)
-		log.Println(r.RemoteAddr, startTime.String(),
-			r.Method, r.URL.Path, r.Proto, httpWriter.status,
-			time.Since(startTime), r.UserAgent())
+		elapsed := time.Since(startTime)
+
+		attrs := []slog.Attr{
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("route", route),
+			slog.String("proto", r.Proto),
+			slog.Int("status", httpWriter.status),
+			slog.Duration("elapsed", elapsed),
+			slog.String("user_agent", r.UserAgent()),
+		}
+
+		level := slog.LevelInfo
+		if httpWriter.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		if httpWriter.err != nil {
+			attrs = append(attrs, slog.String("error", httpWriter.err.Error()))
+		}
+
+		slog.LogAttrs(r.Context(), level, "request", attrs...)
+		metrics.ObserveHTTP(route, r.Method, httpWriter.status, elapsed)
+	})
+}
+
+// authMiddleware requires an API key with the route scope, sent either as a
+// bearer token or in the X-API-Key header. The key is passed on in the request
+// context.
+func authMiddleware(a app.Application, scope auth.Scope, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			key = strings.TrimSpace(bearer)
+		}
+
+		apiKey, err := a.Authenticate(r.Context(), key, scope)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithKey(r.Context(), apiKey)))
+	})
+}
+
+// tenantMiddleware scopes the request to the tenant of its API key or, when
+// the request is not authenticated, to the default tenant.
+func tenantMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey, authenticated := auth.KeyFrom(r.Context())
+
+		tenantID, err := tenant.Resolve(apiKey.Tenant, authenticated, r.Header.Get(tenant.Header))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(tenant.WithTenant(r.Context(), tenantID)))
+	})
+}
+
+// rateLimitMiddleware refuses the requests of the clients that ran out of
+// tokens with 429 and Retry-After. clientKey picks the bucket of the request.
+func rateLimitMiddleware(limiter *ratelimit.Limiter, clientKey func(r *http.Request) string,
+	next http.Handler,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, retryAfter := limiter.Allow(clientKey(r)); !ok {
+			writeError(w, apperror.RateLimited(retryAfter))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func apiKeyID(r *http.Request) string {
+	apiKey, _ := auth.KeyFrom(r.Context())
+	return strconv.Itoa(apiKey.ID)
+}
+
+// timeoutMiddleware bounds the request context, so the queries of a slow
+// request are cancelled with it.
+func timeoutMiddleware(timeout time.Duration, next http.Handler) http.Handler {
+	if timeout <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// deprecatedMiddleware marks responses of the legacy routes with the
+// Deprecation header and a link to the /v1 replacement.
+func deprecatedMiddleware(successor string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		next.ServeHTTP(w, r)
 	})
 }
@@ -0,0 +1,50 @@
+package internalhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/logger"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     string
+		wantID string
+	}{
+		{name: "sent by the client", id: "req-1", wantID: "req-1"},
+		{name: "missing"},
+		{name: "invalid", id: "req 1"},
+		{name: "too long", id: strings.Repeat("a", 129)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ctxID string
+			handler := requestIDMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				ctxID = logger.RequestID(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.id != "" {
+				r.Header.Set(logger.RequestIDHeader, tt.id)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			id := w.Header().Get(logger.RequestIDHeader)
+			if tt.wantID != "" && id != tt.wantID {
+				t.Errorf("request ID = %q, want %q", id, tt.wantID)
+			}
+			if tt.wantID == "" && (id == tt.id || !logger.ValidRequestID(id)) {
+				t.Errorf("request ID = %q, want a new one", id)
+			}
+			if ctxID != id {
+				t.Errorf("request ID of the context = %q, want %q", ctxID, id)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package internalhttp
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+var specMethods = []string{
+	http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete,
+	http.MethodPatch, http.MethodHead, http.MethodOptions,
+}
+
+func serveOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(openAPISpec)
+}
+
+// checkSpec compares the registered mux patterns with the OpenAPI document and
+// reports routes missing from it and documented operations without a route.
+// Patterns without a method match any documented method of their path.
+func checkSpec(spec []byte, patterns []string) error {
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return fmt.Errorf("invalid openapi document: %w", err)
+	}
+
+	documented := make(map[string]struct{})
+	for path, item := range doc.Paths {
+		for _, method := range specMethods {
+			if _, ok := item[strings.ToLower(method)]; ok {
+				documented[method+" "+path] = struct{}{}
+			}
+		}
+	}
+
+	registered := make(map[string]struct{}, len(patterns))
+	errs := make([]error, 0)
+
+	for _, pattern := range patterns {
+		method, path, hasMethod := strings.Cut(pattern, " ")
+		if !hasMethod {
+			method, path = "", pattern
+		}
+		registered[pattern] = struct{}{}
+
+		if method != "" {
+			if _, ok := documented[pattern]; !ok {
+				errs = append(errs, fmt.Errorf("route %q is not documented", pattern))
+			}
+			continue
+		}
+
+		if len(doc.Paths[path]) == 0 {
+			errs = append(errs, fmt.Errorf("route %q is not documented", pattern))
+		}
+	}
+
+	for operation := range documented {
+		_, path, _ := strings.Cut(operation, " ")
+		_, exact := registered[operation]
+		_, anyMethod := registered[path]
+
+		if !exact && !anyMethod {
+			errs = append(errs, fmt.Errorf("documented operation %q has no route", operation))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,49 @@
+package internalhttp
+
+import (
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/config"
+	"github.com/otus-murashko/banners-rotation/internal/ratelimit"
+)
+
+func TestRoutesMatchSpec(t *testing.T) {
+	for _, auth := range []bool{false, true} {
+		_, patterns := newRouter(nil, config.Server{Auth: auth}, ratelimit.New(0, 0), ratelimit.New(0, 0))
+
+		if err := checkSpec(openAPISpec, patterns); err != nil {
+			t.Errorf("auth %v: openapi.json is out of date:\n%s", auth, err)
+		}
+	}
+}
+
+func TestCheckSpec(t *testing.T) {
+	spec := []byte(`{"paths": {
+		"/v1/items": {"get": {}, "post": {}},
+		"/legacy": {"get": {}, "delete": {}}
+	}}`)
+
+	tests := []struct {
+		name     string
+		patterns []string
+		wantErr  bool
+	}{
+		{name: "all documented", patterns: []string{"GET /v1/items", "POST /v1/items", "/legacy"}},
+		{name: "route not documented", patterns: []string{"GET /v1/items", "POST /v1/items", "/legacy", "PUT /v1/items"}, wantErr: true},
+		{name: "path not documented", patterns: []string{"GET /v1/items", "POST /v1/items", "/legacy", "/other"}, wantErr: true},
+		{name: "operation without route", patterns: []string{"GET /v1/items", "/legacy"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSpec(spec, tt.patterns)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := checkSpec([]byte("{"), nil); err == nil {
+		t.Error("checkSpec() accepted an invalid document")
+	}
+}
@@ -0,0 +1,216 @@
+package internalhttp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/otus-murashko/banners-rotation/internal/app"
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code    apperror.Kind     `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// writeError responds with the JSON error body and the status matching the
+// error kind. The error is logged with the request and internal errors are
+// hidden from the client.
+func writeError(w http.ResponseWriter, err error) {
+	kind := apperror.KindOf(err)
+	recordError(w, err)
+
+	data, _ := json.Marshal(errorResponse{Error: errorBody{
+		Code:    kind,
+		Message: apperror.MessageOf(err),
+		Fields:  apperror.FieldsOf(err),
+	}})
+
+	if retryAfter, ok := apperror.RetryAfterOf(err); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(errorStatus(kind))
+	w.Write(data)
+}
+
+func errorStatus(kind apperror.Kind) int {
+	switch kind {
+	case apperror.KindNotFound:
+		return http.StatusNotFound
+	case apperror.KindConflict:
+		return http.StatusConflict
+	case apperror.KindValidation:
+		return http.StatusBadRequest
+	case apperror.KindUnauthenticated:
+		return http.StatusUnauthorized
+	case apperror.KindPermissionDenied:
+		return http.StatusForbidden
+	case apperror.KindRateLimited:
+		return http.StatusTooManyRequests
+	case apperror.KindUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+const (
+	// maxBodySize limits the JSON body of a request.
+	maxBodySize = 1 << 20
+	// maxEventsBodySize limits the body of a batch of events.
+	maxEventsBodySize = 8 << 20
+)
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		return bodyError(err)
+	}
+
+	if err = json.Unmarshal(body, v); err != nil {
+		return apperror.Wrap(apperror.KindValidation, err, "invalid JSON body: %s", err.Error())
+	}
+
+	return nil
+}
+
+// readEvents decodes the events sent either as a JSON array or as a stream of
+// newline-delimited JSON objects. The body is not read past the first event
+// over the batch limit.
+func readEvents(w http.ResponseWriter, r *http.Request) ([]storage.Event, error) {
+	body := bufio.NewReader(http.MaxBytesReader(w, r.Body, maxEventsBodySize))
+
+	array, err := startsArray(body)
+	if err != nil {
+		return nil, bodyError(err)
+	}
+
+	dec := json.NewDecoder(body)
+	if array {
+		// the opening bracket
+		if _, err = dec.Token(); err != nil {
+			return nil, bodyError(err)
+		}
+	}
+
+	events := make([]storage.Event, 0)
+	for n := 1; dec.More(); n++ {
+		if len(events) == app.MaxBatchEvents {
+			return nil, apperror.InvalidFields(map[string]string{
+				"Events": fmt.Sprintf("must not contain more than %d events", app.MaxBatchEvents),
+			})
+		}
+
+		var event storage.Event
+		if err = dec.Decode(&event); err != nil {
+			return nil, eventError(n, err)
+		}
+		events = append(events, event)
+	}
+
+	if array {
+		if _, err = dec.Token(); err != nil {
+			return nil, bodyError(err)
+		}
+	}
+
+	return events, nil
+}
+
+// startsArray skips the leading white space and reports whether the body is
+// a JSON array.
+func startsArray(body *bufio.Reader) (bool, error) {
+	for {
+		b, err := body.Peek(1)
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			body.ReadByte()
+		default:
+			return b[0] == '[', nil
+		}
+	}
+}
+
+// bodyError is the error of a request body that could not be read or decoded.
+func bodyError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return apperror.Validation("request body must not be larger than %d bytes", tooLarge.Limit)
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return apperror.Wrap(apperror.KindValidation, err, "invalid JSON body: %s", err.Error())
+	}
+
+	return apperror.Wrap(apperror.KindValidation, err, "failed to read request body")
+}
+
+func eventError(n int, err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return bodyError(err)
+	}
+
+	return apperror.Wrap(apperror.KindValidation, err, "invalid JSON in event %d: %s", n, err.Error())
+}
+
+// queryInt parses an integer query parameter. Missing optional parameters are zero.
+func queryInt(r *http.Request, name string, required bool) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		if required {
+			return 0, apperror.Validation("%s is required", name)
+		}
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, apperror.Validation("%s must be an integer", name)
+	}
+
+	return n, nil
+}
+
+func pathInt(r *http.Request, name string) (int, error) {
+	n, err := strconv.Atoi(r.PathValue(name))
+	if err != nil {
+		return 0, apperror.Validation("%s must be an integer", name)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,282 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/app"
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantStatus     int
+		wantCode       apperror.Kind
+		wantMessage    string
+		wantFields     map[string]string
+		wantRetryAfter string
+	}{
+		{
+			name:        "not found",
+			err:         apperror.NotFound("banner %d not found", 1),
+			wantStatus:  http.StatusNotFound,
+			wantCode:    apperror.KindNotFound,
+			wantMessage: "banner 1 not found",
+		},
+		{
+			name:        "conflict",
+			err:         apperror.Conflict("already exists"),
+			wantStatus:  http.StatusConflict,
+			wantCode:    apperror.KindConflict,
+			wantMessage: "already exists",
+		},
+		{
+			name:        "invalid fields",
+			err:         apperror.InvalidFields(map[string]string{"SlotID": "must be positive"}),
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    apperror.KindValidation,
+			wantMessage: apperror.MessageOf(apperror.InvalidFields(nil)),
+			wantFields:  map[string]string{"SlotID": "must be positive"},
+		},
+		{
+			name:        "unauthenticated",
+			err:         apperror.Unauthenticated("API key is required"),
+			wantStatus:  http.StatusUnauthorized,
+			wantCode:    apperror.KindUnauthenticated,
+			wantMessage: "API key is required",
+		},
+		{
+			name:        "permission denied",
+			err:         apperror.PermissionDenied("scope admin is required"),
+			wantStatus:  http.StatusForbidden,
+			wantCode:    apperror.KindPermissionDenied,
+			wantMessage: "scope admin is required",
+		},
+		{
+			name:           "rate limited",
+			err:            apperror.RateLimited(1500 * time.Millisecond),
+			wantStatus:     http.StatusTooManyRequests,
+			wantCode:       apperror.KindRateLimited,
+			wantMessage:    apperror.MessageOf(apperror.RateLimited(time.Second)),
+			wantRetryAfter: "2",
+		},
+		{
+			name:        "unavailable",
+			err:         apperror.Unavailable("database is down"),
+			wantStatus:  http.StatusServiceUnavailable,
+			wantCode:    apperror.KindUnavailable,
+			wantMessage: "database is down",
+		},
+		{
+			name:        "wrapped domain error",
+			err:         fmt.Errorf("get banner: %w", apperror.NotFound("banner 1 not found")),
+			wantStatus:  http.StatusNotFound,
+			wantCode:    apperror.KindNotFound,
+			wantMessage: "banner 1 not found",
+		},
+		{
+			name:        "internal error is hidden",
+			err:         errors.New("connection refused"),
+			wantStatus:  http.StatusInternalServerError,
+			wantCode:    apperror.KindInternal,
+			wantMessage: "internal error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writeError(rec, tt.err)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+
+			var body errorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid error body %q: %v", rec.Body.String(), err)
+			}
+			if body.Error.Code != tt.wantCode || body.Error.Message != tt.wantMessage {
+				t.Errorf("error = %q %q, want %q %q", body.Error.Code, body.Error.Message, tt.wantCode, tt.wantMessage)
+			}
+			if !maps.Equal(body.Error.Fields, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", body.Error.Fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestWriteErrorRecordsError(t *testing.T) {
+	err := apperror.NotFound("banner 1 not found")
+	sw := &statusWriter{ResponseWriter: httptest.NewRecorder()}
+
+	writeError(&responseRecorder{ResponseWriter: sw}, err)
+
+	if !errors.Is(sw.err, err) {
+		t.Errorf("recorded error = %v, want %v", sw.err, err)
+	}
+}
+
+func TestReadEvents(t *testing.T) {
+	show := storage.Event{Type: storage.EventShow, BannerID: 1, SlotID: 2, SosialGroupID: 3}
+	click := storage.Event{Type: storage.EventClick, BannerID: 1, SlotID: 2, SosialGroupID: 3, ImpressionID: 7}
+
+	tests := []struct {
+		name        string
+		body        string
+		want        []storage.Event
+		wantMessage string
+	}{
+		{
+			name: "array",
+			body: `[{"Type":"show","BannerID":1,"SlotID":2,"SosialGroupID":3},
+				{"Type":"click","BannerID":1,"SlotID":2,"SosialGroupID":3,"ImpressionID":7}]`,
+			want: []storage.Event{show, click},
+		},
+		{
+			name: "array after white space",
+			body: "\n\t [{\"Type\":\"show\",\"BannerID\":1,\"SlotID\":2,\"SosialGroupID\":3}]",
+			want: []storage.Event{show},
+		},
+		{
+			name: "newline-delimited",
+			body: `{"Type":"show","BannerID":1,"SlotID":2,"SosialGroupID":3}
+{"Type":"click","BannerID":1,"SlotID":2,"SosialGroupID":3,"ImpressionID":7}
+`,
+			want: []storage.Event{show, click},
+		},
+		{name: "empty body", body: "", want: []storage.Event{}},
+		{name: "empty array", body: "[]", want: []storage.Event{}},
+		{
+			name:        "invalid event",
+			body:        `{"Type":"show"}` + "\n" + `{"Type":`,
+			wantMessage: "invalid JSON in event 2",
+		},
+		{
+			name:        "unclosed array",
+			body:        `[{"Type":"show"}`,
+			wantMessage: "invalid JSON in event 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/v1/events", strings.NewReader(tt.body))
+
+			got, err := readEvents(httptest.NewRecorder(), r)
+			if tt.wantMessage != "" {
+				if apperror.KindOf(err) != apperror.KindValidation || !strings.Contains(apperror.MessageOf(err), tt.wantMessage) {
+					t.Fatalf("readEvents() error = %v, want a validation error %q", err, tt.wantMessage)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("readEvents() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("readEvents() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadEventsStopsAtLimit(t *testing.T) {
+	event := `{"Type":"show","BannerID":1,"SlotID":2,"SosialGroupID":3}` + "\n"
+
+	tests := []struct {
+		name    string
+		events  int
+		wantErr bool
+	}{
+		{name: "at the limit", events: app.MaxBatchEvents},
+		{name: "over the limit", events: app.MaxBatchEvents + 1000, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := strings.NewReader(strings.Repeat(event, tt.events))
+			r := httptest.NewRequest(http.MethodPost, "/v1/events", body)
+
+			events, err := readEvents(httptest.NewRecorder(), r)
+			if !tt.wantErr {
+				if err != nil || len(events) != tt.events {
+					t.Errorf("readEvents() = %d events, %v, want %d events", len(events), err, tt.events)
+				}
+				return
+			}
+
+			if _, ok := apperror.FieldsOf(err)["Events"]; !ok {
+				t.Fatalf("readEvents() error = %v, want an Events field error", err)
+			}
+			if body.Len() == 0 {
+				t.Error("readEvents() read the body past the limit")
+			}
+		})
+	}
+}
+
+func TestReadBodyLimit(t *testing.T) {
+	big := `{"Descr":"` + strings.Repeat("a", maxBodySize) + `"}`
+	r := httptest.NewRequest(http.MethodPost, "/v1/banners", strings.NewReader(big))
+
+	var banner storage.Banner
+	err := readJSON(httptest.NewRecorder(), r, &banner)
+	if apperror.KindOf(err) != apperror.KindValidation || !strings.Contains(apperror.MessageOf(err), "must not be larger") {
+		t.Errorf("readJSON() error = %v, want the body size error", err)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/v1/events", strings.NewReader(strings.Repeat(" ", maxEventsBodySize+1)))
+	if _, err = readEvents(httptest.NewRecorder(), r); !strings.Contains(apperror.MessageOf(err), "must not be larger") {
+		t.Errorf("readEvents() error = %v, want the body size error", err)
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    storage.Banner
+		wantErr bool
+	}{
+		{name: "object", body: `{"Descr":"banner","Bid":1.5}`, want: storage.Banner{Descr: "banner", Bid: 1.5}},
+		{name: "invalid", body: `{"Descr":`, wantErr: true},
+		{name: "trailing data", body: `{"Descr":"banner"} {}`, wantErr: true},
+		{name: "empty", body: ``, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/v1/banners", strings.NewReader(tt.body))
+
+			var got storage.Banner
+			err := readJSON(httptest.NewRecorder(), r, &got)
+			if tt.wantErr {
+				if apperror.KindOf(err) != apperror.KindValidation {
+					t.Errorf("readJSON() error = %v, want a validation error", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("readJSON() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -3,16 +3,27 @@ package internalhttp
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 
 	"github.com/otus-murashko/banners-rotation/internal/app"
+	"github.com/otus-murashko/banners-rotation/internal/auth"
 	"github.com/otus-murashko/banners-rotation/internal/config"
+	"github.com/otus-murashko/banners-rotation/internal/ratelimit"
+	"github.com/otus-murashko/banners-rotation/internal/tlsconfig"
 )
 
 type Server struct {
 	server *http.Server
 	app    app.Application
+	// cancel aborts the requests still running when shutdown times out
+	cancel context.CancelFunc
+
+	keyLimiter *ratelimit.Limiter
+	ipLimiter  *ratelimit.Limiter
+	// tls is nil when the server speaks plain HTTP
+	tls *tlsconfig.Reloader
 }
 
 type ServerConf struct {
@@ -20,38 +31,149 @@ type ServerConf struct {
 	Port int
 }
 
-func NewServer(app app.Application, conf config.Server) *Server {
-	bannerRouter := http.NewServeMux()
+func NewServer(app app.Application, conf config.Server) (*Server, error) {
+	// the rotation and statistic routes feed the bandits, so a single client
+	// must not flood them
+	keyLimiter := ratelimit.New(conf.RateLimit.PerKey.Rate, conf.RateLimit.PerKey.Burst)
+	ipLimiter := ratelimit.New(conf.RateLimit.PerIP.Rate, conf.RateLimit.PerIP.Burst)
 
-	appHandler := Handler{
-		app: app,
-	}
+	bannerRouter, _ := newRouter(app, conf, keyLimiter, ipLimiter)
 
-	bannerRouter.Handle("/banner-rotation", loggingMiddleware(http.HandlerFunc(appHandler.bannerRotationHandler)))
-	bannerRouter.Handle("/banner", loggingMiddleware(http.HandlerFunc(appHandler.bannerHandler)))
-	bannerRouter.Handle("/slot", loggingMiddleware(http.HandlerFunc(appHandler.slotHandler)))
-	bannerRouter.Handle("/group", loggingMiddleware(http.HandlerFunc(appHandler.groupHandler)))
-	bannerRouter.Handle("/stat", loggingMiddleware(http.HandlerFunc(appHandler.statHandler)))
+	baseCtx, cancel := context.WithCancel(context.Background())
 
 	httpServer := &http.Server{
 		ReadHeaderTimeout: 3 * time.Second,
 		Addr:              fmt.Sprintf("%s:%d", conf.Host, conf.Port),
 		Handler:           bannerRouter,
+		BaseContext: func(net.Listener) context.Context {
+			return baseCtx
+		},
+	}
+	var tls *tlsconfig.Reloader
+	if conf.TLS.CertFile != "" {
+		var err error
+		if tls, err = tlsconfig.New(conf.TLS); err != nil {
+			cancel()
+			return nil, fmt.Errorf("tls: %w", err)
+		}
+		httpServer.TLSConfig = tls.Config()
 	}
+
 	return &Server{
-		server: httpServer,
-		app:    app,
+		server:     httpServer,
+		app:        app,
+		cancel:     cancel,
+		keyLimiter: keyLimiter,
+		ipLimiter:  ipLimiter,
+		tls:        tls,
+	}, nil
+}
+
+// newRouter registers the routes on a new mux and returns their patterns,
+// every one of them must be described in openapi.json.
+func newRouter(app app.Application, conf config.Server, keyLimiter, ipLimiter *ratelimit.Limiter,
+) (*http.ServeMux, []string) {
+	bannerRouter := http.NewServeMux()
+
+	appHandler := Handler{
+		app: app,
 	}
+
+	patterns := make([]string, 0)
+	// routes without a scope are public
+	handle := func(pattern string, scope auth.Scope, handler http.Handler) {
+		patterns = append(patterns, pattern)
+
+		timeout := conf.RequestTimeout
+		if routeTimeout, ok := conf.RouteTimeouts[pattern]; ok {
+			timeout = routeTimeout
+		}
+
+		// the key is held no longer than the request may run
+		handler = tenantMiddleware(idempotencyMiddleware(app, conf.IdempotencyWindow, timeout, handler))
+		if conf.Auth && scope != "" {
+			if scope == auth.ScopeTrack {
+				handler = rateLimitMiddleware(keyLimiter, apiKeyID, handler)
+			}
+			handler = authMiddleware(app, scope, handler)
+		}
+		if scope == auth.ScopeTrack {
+			handler = rateLimitMiddleware(ipLimiter, clientIP, handler)
+		}
+
+		bannerRouter.Handle(pattern,
+			requestIDMiddleware(tracingMiddleware(pattern, loggingMiddleware(pattern, timeoutMiddleware(timeout, handler)))))
+	}
+
+	handle("GET /v1/slots/{id}/banner", auth.ScopeTrack, appHandler.with(getSlotBanner))
+	handle("POST /v1/slots/banners", auth.ScopeTrack, appHandler.with(getSlotsBanners))
+	handle("PUT /v1/slots/{id}/banners/{bannerID}", auth.ScopeAdmin, appHandler.with(putSlotBanner))
+	handle("DELETE /v1/slots/{id}/banners/{bannerID}", auth.ScopeAdmin, appHandler.with(deleteSlotBanner))
+	handle("POST /v1/slots/{id}/banners/{bannerID}/clicks", auth.ScopeTrack, appHandler.with(addSlotBannerClick))
+	handle("POST /v1/banners", auth.ScopeAdmin, appHandler.with(addBanner))
+	handle("POST /v1/slots", auth.ScopeAdmin, appHandler.with(addSlot))
+	handle("POST /v1/groups", auth.ScopeAdmin, appHandler.with(addGroup))
+	handle("POST /v1/events", auth.ScopeTrack, appHandler.with(recordEvents))
+	handle("POST /v1/conversions", auth.ScopeTrack, appHandler.with(trackConversion))
+	handle("POST /v1/experiments", auth.ScopeAdmin, appHandler.with(addExperiment))
+	handle("GET /v1/experiments/{id}/report", auth.ScopeRead, appHandler.with(getExperimentReportByID))
+	handle("GET /v1/reports/revenue", auth.ScopeRead, appHandler.with(getRevenueReport))
+	handle("GET /openapi.json", "", http.HandlerFunc(serveOpenAPI))
+	handle("GET /healthz", "", http.HandlerFunc(getHealth))
+	handle("GET /readyz", "", appHandler.with(getReadiness))
+
+	// Deprecated: the legacy routes are kept until clients move to /v1
+	legacy := func(pattern, successor string, scope auth.Scope, handler http.HandlerFunc) {
+		handle(pattern, scope, deprecatedMiddleware(successor, handler))
+	}
+
+	// the rotation is read and managed on one legacy path, the methods need
+	// different scopes
+	legacy("GET /banner-rotation", "/v1/slots/{id}/banner", auth.ScopeTrack, appHandler.bannerRotationHandler)
+	legacy("POST /banner-rotation", "/v1/slots/{id}/banners/{bannerID}", auth.ScopeAdmin, appHandler.bannerRotationHandler)
+	legacy("DELETE /banner-rotation", "/v1/slots/{id}/banners/{bannerID}", auth.ScopeAdmin, appHandler.bannerRotationHandler)
+	legacy("/banner", "/v1/banners", auth.ScopeAdmin, appHandler.bannerHandler)
+	legacy("/slot", "/v1/slots", auth.ScopeAdmin, appHandler.slotHandler)
+	legacy("/group", "/v1/groups", auth.ScopeAdmin, appHandler.groupHandler)
+	legacy("/stat", "/v1/slots/{id}/banners/{bannerID}/clicks", auth.ScopeTrack, appHandler.statHandler)
+	legacy("/conversion", "/v1/conversions", auth.ScopeTrack, appHandler.conversionHandler)
+	legacy("/experiment", "/v1/experiments", auth.ScopeAdmin, appHandler.experimentHandler)
+	legacy("/experiment-stat", "/v1/experiments/{id}/report", auth.ScopeRead, appHandler.experimentStatHandler)
+	legacy("/revenue-stat", "/v1/reports/revenue", auth.ScopeRead, appHandler.revenueStatHandler)
+
+	return bannerRouter, patterns
+}
+
+// ReloadTLS loads the certificate files again, the running connections keep
+// the certificate they were opened with.
+func (s *Server) ReloadTLS() error {
+	if s.tls == nil {
+		return nil
+	}
+	return s.tls.Reload()
+}
+
+// SetRateLimit applies new limits to the requests in flight and to come.
+func (s *Server) SetRateLimit(conf config.RateLimit) {
+	s.keyLimiter.SetLimit(conf.PerKey.Rate, conf.PerKey.Burst)
+	s.ipLimiter.SetLimit(conf.PerIP.Rate, conf.PerIP.Burst)
 }
 
 func (s *Server) Start(ctx context.Context) error {
-	err := s.server.ListenAndServe()
+	var err error
+	if s.tls != nil {
+		// the certificate comes from the TLS config
+		err = s.server.ListenAndServeTLS("", "")
+	} else {
+		err = s.server.ListenAndServe()
+	}
 	ctx.Done()
 	return err
 }
 
 func (s *Server) Stop(ctx context.Context) error {
 	err := s.server.Shutdown(ctx)
+	s.cancel()
 	<-ctx.Done()
 	return err
 }
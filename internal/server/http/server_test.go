@@ -0,0 +1,131 @@
+package internalhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/app"
+	"github.com/otus-murashko/banners-rotation/internal/config"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+// rotationApp records the rotations the handlers add and delete.
+type rotationApp struct {
+	app.Application
+	added   []storage.Rotation
+	deleted []storage.Rotation
+}
+
+func (a *rotationApp) AddBannerToSlot(_ context.Context, rotation storage.Rotation) error {
+	a.added = append(a.added, rotation)
+	return nil
+}
+
+func (a *rotationApp) DeleteBannerFromSlot(_ context.Context, bannerID int, slotID int) error {
+	a.deleted = append(a.deleted, storage.Rotation{BannerID: bannerID, SlotID: slotID})
+	return nil
+}
+
+func TestRoutes(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		body        string
+		wantStatus  int
+		wantAdded   []storage.Rotation
+		wantDeleted []storage.Rotation
+		wantLink    string
+	}{
+		{
+			name:       "put banner",
+			method:     http.MethodPut,
+			path:       "/v1/slots/3/banners/7",
+			wantStatus: http.StatusOK,
+			wantAdded:  []storage.Rotation{{BannerID: 7, SlotID: 3}},
+		},
+		{
+			name:        "delete banner",
+			method:      http.MethodDelete,
+			path:        "/v1/slots/3/banners/7",
+			wantStatus:  http.StatusNoContent,
+			wantDeleted: []storage.Rotation{{BannerID: 7, SlotID: 3}},
+		},
+		{
+			name:       "invalid path parameter",
+			method:     http.MethodPut,
+			path:       "/v1/slots/three/banners/7",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "method not allowed",
+			method:     http.MethodGet,
+			path:       "/v1/slots",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "legacy add",
+			method:     http.MethodPost,
+			path:       "/banner-rotation",
+			body:       `{"BannerID": 7, "SlotID": 3}`,
+			wantStatus: http.StatusOK,
+			wantAdded:  []storage.Rotation{{BannerID: 7, SlotID: 3}},
+			wantLink:   `</v1/slots/{id}/banners/{bannerID}>; rel="successor-version"`,
+		},
+		{
+			name:        "legacy delete",
+			method:      http.MethodDelete,
+			path:        "/banner-rotation",
+			body:        `{"BannerID": 7, "SlotID": 3}`,
+			wantStatus:  http.StatusOK,
+			wantDeleted: []storage.Rotation{{BannerID: 7, SlotID: 3}},
+			wantLink:    `</v1/slots/{id}/banners/{bannerID}>; rel="successor-version"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &rotationApp{}
+			handler := newTestHandler(t, a, config.Server{})
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if !equalRotations(a.added, tt.wantAdded) {
+				t.Errorf("added = %v, want %v", a.added, tt.wantAdded)
+			}
+			if !equalRotations(a.deleted, tt.wantDeleted) {
+				t.Errorf("deleted = %v, want %v", a.deleted, tt.wantDeleted)
+			}
+
+			wantDeprecation := ""
+			if tt.wantLink != "" {
+				wantDeprecation = "true"
+			}
+			if got := w.Header().Get("Deprecation"); got != wantDeprecation {
+				t.Errorf("Deprecation = %q, want %q", got, wantDeprecation)
+			}
+			if got := w.Header().Get("Link"); got != tt.wantLink {
+				t.Errorf("Link = %q, want %q", got, tt.wantLink)
+			}
+		})
+	}
+}
+
+func equalRotations(got, want []storage.Rotation) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i].BannerID != want[i].BannerID || got[i].SlotID != want[i].SlotID {
+			return false
+		}
+	}
+	return true
+}
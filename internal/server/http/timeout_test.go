@@ -0,0 +1,107 @@
+package internalhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/app"
+	"github.com/otus-murashko/banners-rotation/internal/config"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+)
+
+// newTestHandler returns the routes of a server started with conf.
+func newTestHandler(t *testing.T, a app.Application, conf config.Server) http.Handler {
+	t.Helper()
+
+	server, err := NewServer(a, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server.server.Handler
+}
+
+// deadlineApp records the deadline of the context the handlers call it with.
+// The other methods of the application are not used by the test.
+type deadlineApp struct {
+	app.Application
+	deadline time.Time
+}
+
+func (a *deadlineApp) CreateBanner(ctx context.Context, _ storage.Banner) (int, error) {
+	a.deadline, _ = ctx.Deadline()
+	return 1, nil
+}
+
+func (a *deadlineApp) CreateSlot(ctx context.Context, _ string) (int, error) {
+	a.deadline, _ = ctx.Deadline()
+	return 1, nil
+}
+
+func TestRouteTimeouts(t *testing.T) {
+	conf := config.Server{
+		RequestTimeout: time.Hour,
+		RouteTimeouts:  map[string]time.Duration{"/banner": time.Minute},
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		wantTimeout time.Duration
+	}{
+		{name: "route timeout", path: "/banner", wantTimeout: time.Minute},
+		{name: "request timeout", path: "/slot", wantTimeout: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &deadlineApp{}
+			handler := newTestHandler(t, a, conf)
+
+			start := time.Now()
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(`{"Descr": "new"}`)))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+			}
+			if a.deadline.Before(start.Add(tt.wantTimeout)) || a.deadline.After(time.Now().Add(tt.wantTimeout)) {
+				t.Errorf("request timeout = %s, want %s", a.deadline.Sub(start), tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	t.Run("without timeout", func(t *testing.T) {
+		var hasDeadline bool
+		handler := timeoutMiddleware(0, http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			_, hasDeadline = r.Context().Deadline()
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if hasDeadline {
+			t.Error("request has a deadline, want none")
+		}
+	})
+
+	t.Run("timed out", func(t *testing.T) {
+		var err error
+		handler := timeoutMiddleware(10*time.Millisecond, http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+				err = r.Context().Err()
+			case <-time.After(time.Second):
+			}
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("request context error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+}
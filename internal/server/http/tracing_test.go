@@ -0,0 +1,73 @@
+package internalhttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	tests := []struct {
+		name        string
+		traceparent string
+		err         error
+		wantStatus  codes.Code
+	}{
+		{name: "new trace"},
+		{name: "trace of the caller", traceparent: "00-" + traceID + "-00f067aa0ba902b7-01"},
+		{name: "client error", err: apperror.NotFound("slot 1 not found")},
+		{name: "server error", err: errors.New("connection reset"), wantStatus: codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+			handler := tracingMiddleware("GET /v1/slots/{id}/banners", http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					if tt.err != nil {
+						writeError(w, tt.err)
+					}
+				}))
+
+			r := httptest.NewRequest(http.MethodGet, "/v1/slots/1/banners", nil)
+			if tt.traceparent != "" {
+				r.Header.Set("traceparent", tt.traceparent)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("ended spans = %d, want 1", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != "GET /v1/slots/{id}/banners" || span.SpanKind() != trace.SpanKindServer {
+				t.Errorf("span = %s of kind %s, want the route of kind server", span.Name(), span.SpanKind())
+			}
+			if tt.traceparent != "" && span.SpanContext().TraceID().String() != traceID {
+				t.Errorf("trace ID = %s, want the one of the caller %s", span.SpanContext().TraceID(), traceID)
+			}
+			if span.Status().Code != tt.wantStatus {
+				t.Errorf("span status = %s, want %s", span.Status().Code, tt.wantStatus)
+			}
+		})
+	}
+}
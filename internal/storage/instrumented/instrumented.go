@@ -0,0 +1,283 @@
+// Package instrumented records the latency and the errors of every storage
+// operation in the metrics and starts a span for it.
+package instrumented
+
+import (
+	"context"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/metrics"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+	"github.com/otus-murashko/banners-rotation/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type Storage struct {
+	next storage.Storage
+}
+
+func New(s storage.Storage) storage.Storage {
+	return Storage{next: s}
+}
+
+// observe runs the operation in a span and records its latency. Not found
+// errors are expected and not recorded as failures.
+func observe(ctx context.Context, operation string, op func(ctx context.Context) error) error {
+	ctx, span := tracing.Start(ctx, "storage."+operation, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation.name", operation),
+		))
+	start := time.Now()
+	err := op(ctx)
+	metrics.ObserveStorage(operation, time.Since(start), err)
+
+	spanErr := err
+	if apperror.KindOf(err) == apperror.KindNotFound {
+		spanErr = nil
+	}
+	tracing.End(span, spanErr)
+
+	return err
+}
+
+func observeResult[T any](ctx context.Context, operation string, op func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := observe(ctx, operation, func(ctx context.Context) (err error) {
+		result, err = op(ctx)
+		return err
+	})
+
+	return result, err
+}
+
+func (s Storage) Connect() error {
+	return s.next.Connect()
+}
+
+func (s Storage) Close() error {
+	return s.next.Close()
+}
+
+func (s Storage) Ping(ctx context.Context) error {
+	return observe(ctx, "Ping", func(ctx context.Context) error {
+		return s.next.Ping(ctx)
+	})
+}
+
+func (s Storage) GetBannersBySlot(ctx context.Context, slotID int) ([]int, error) {
+	return observeResult(ctx, "GetBannersBySlot", func(ctx context.Context) ([]int, error) {
+		return s.next.GetBannersBySlot(ctx, slotID)
+	})
+}
+
+func (s Storage) GetBannersStat(ctx context.Context, slotID int, groupID int, bannerIDs []int) ([]storage.Statistic, error) {
+	return observeResult(ctx, "GetBannersStat", func(ctx context.Context) ([]storage.Statistic, error) {
+		return s.next.GetBannersStat(ctx, slotID, groupID, bannerIDs)
+	})
+}
+
+func (s Storage) GetSlotRotations(ctx context.Context, slotID int) ([]storage.Rotation, error) {
+	return observeResult(ctx, "GetSlotRotations", func(ctx context.Context) ([]storage.Rotation, error) {
+		return s.next.GetSlotRotations(ctx, slotID)
+	})
+}
+
+func (s Storage) AddBannerToSlot(ctx context.Context, bannerID int, slotID int, targeting storage.Targeting) error {
+	return observe(ctx, "AddBannerToSlot", func(ctx context.Context) error {
+		return s.next.AddBannerToSlot(ctx, bannerID, slotID, targeting)
+	})
+}
+
+func (s Storage) DeleteBannerFromSlot(ctx context.Context, bannerID int, slotID int) error {
+	return observe(ctx, "DeleteBannerFromSlot", func(ctx context.Context) error {
+		return s.next.DeleteBannerFromSlot(ctx, bannerID, slotID)
+	})
+}
+
+func (s Storage) CreateBanner(ctx context.Context, banner storage.Banner) (int, error) {
+	return observeResult(ctx, "CreateBanner", func(ctx context.Context) (int, error) {
+		return s.next.CreateBanner(ctx, banner)
+	})
+}
+
+func (s Storage) GetBanners(ctx context.Context, bannerIDs []int) ([]storage.Banner, error) {
+	return observeResult(ctx, "GetBanners", func(ctx context.Context) ([]storage.Banner, error) {
+		return s.next.GetBanners(ctx, bannerIDs)
+	})
+}
+
+func (s Storage) CreateSlot(ctx context.Context, desc string) (int, error) {
+	return observeResult(ctx, "CreateSlot", func(ctx context.Context) (int, error) {
+		return s.next.CreateSlot(ctx, desc)
+	})
+}
+
+func (s Storage) CreateGroup(ctx context.Context, desc string) (int, error) {
+	return observeResult(ctx, "CreateGroup", func(ctx context.Context) (int, error) {
+		return s.next.CreateGroup(ctx, desc)
+	})
+}
+
+func (s Storage) BannerExists(ctx context.Context, bannerID int) (bool, error) {
+	return observeResult(ctx, "BannerExists", func(ctx context.Context) (bool, error) {
+		return s.next.BannerExists(ctx, bannerID)
+	})
+}
+
+func (s Storage) SlotExists(ctx context.Context, slotID int) (bool, error) {
+	return observeResult(ctx, "SlotExists", func(ctx context.Context) (bool, error) {
+		return s.next.SlotExists(ctx, slotID)
+	})
+}
+
+func (s Storage) GroupExists(ctx context.Context, groupID int) (bool, error) {
+	return observeResult(ctx, "GroupExists", func(ctx context.Context) (bool, error) {
+		return s.next.GroupExists(ctx, groupID)
+	})
+}
+
+func (s Storage) UpdateShowStat(ctx context.Context, stat storage.Statistic) error {
+	return observe(ctx, "UpdateShowStat", func(ctx context.Context) error {
+		return s.next.UpdateShowStat(ctx, stat)
+	})
+}
+
+func (s Storage) UpdateClickStat(ctx context.Context, stat storage.Statistic) error {
+	return observe(ctx, "UpdateClickStat", func(ctx context.Context) error {
+		return s.next.UpdateClickStat(ctx, stat)
+	})
+}
+
+func (s Storage) CreateExperiment(ctx context.Context, exp storage.Experiment) (int, error) {
+	return observeResult(ctx, "CreateExperiment", func(ctx context.Context) (int, error) {
+		return s.next.CreateExperiment(ctx, exp)
+	})
+}
+
+func (s Storage) GetExperiment(ctx context.Context, experimentID int) (storage.Experiment, error) {
+	return observeResult(ctx, "GetExperiment", func(ctx context.Context) (storage.Experiment, error) {
+		return s.next.GetExperiment(ctx, experimentID)
+	})
+}
+
+func (s Storage) GetSlotExperiment(ctx context.Context, slotID int) (storage.Experiment, error) {
+	return observeResult(ctx, "GetSlotExperiment", func(ctx context.Context) (storage.Experiment, error) {
+		return s.next.GetSlotExperiment(ctx, slotID)
+	})
+}
+
+func (s Storage) GetExperimentStat(ctx context.Context, experimentID int) ([]storage.ArmStatistic, error) {
+	return observeResult(ctx, "GetExperimentStat", func(ctx context.Context) ([]storage.ArmStatistic, error) {
+		return s.next.GetExperimentStat(ctx, experimentID)
+	})
+}
+
+func (s Storage) UpdateArmShowStat(ctx context.Context, stat storage.Statistic) error {
+	return observe(ctx, "UpdateArmShowStat", func(ctx context.Context) error {
+		return s.next.UpdateArmShowStat(ctx, stat)
+	})
+}
+
+func (s Storage) CreateImpression(ctx context.Context, stat storage.Statistic) (int64, error) {
+	return observeResult(ctx, "CreateImpression", func(ctx context.Context) (int64, error) {
+		return s.next.CreateImpression(ctx, stat)
+	})
+}
+
+func (s Storage) GetImpression(ctx context.Context, impressionID int64) (storage.Impression, error) {
+	return observeResult(ctx, "GetImpression", func(ctx context.Context) (storage.Impression, error) {
+		return s.next.GetImpression(ctx, impressionID)
+	})
+}
+
+func (s Storage) GetImpressions(ctx context.Context, impressionIDs []int64) (map[int64]storage.Impression, error) {
+	return observeResult(ctx, "GetImpressions", func(ctx context.Context) (map[int64]storage.Impression, error) {
+		return s.next.GetImpressions(ctx, impressionIDs)
+	})
+}
+
+func (s Storage) AddConversion(ctx context.Context, conv storage.Conversion) (bool, error) {
+	return observeResult(ctx, "AddConversion", func(ctx context.Context) (bool, error) {
+		return s.next.AddConversion(ctx, conv)
+	})
+}
+
+func (s Storage) GetRevenueStat(ctx context.Context, slotID int) ([]storage.RevenueStatistic, error) {
+	return observeResult(ctx, "GetRevenueStat", func(ctx context.Context) ([]storage.RevenueStatistic, error) {
+		return s.next.GetRevenueStat(ctx, slotID)
+	})
+}
+
+func (s Storage) GetSlotsBanners(ctx context.Context, slotIDs []int, groupID int) ([]storage.SlotBanner, error) {
+	return observeResult(ctx, "GetSlotsBanners", func(ctx context.Context) ([]storage.SlotBanner, error) {
+		return s.next.GetSlotsBanners(ctx, slotIDs, groupID)
+	})
+}
+
+func (s Storage) RecordShows(ctx context.Context, shows []storage.Statistic) (map[int]int64, error) {
+	return observeResult(ctx, "RecordShows", func(ctx context.Context) (map[int]int64, error) {
+		return s.next.RecordShows(ctx, shows)
+	})
+}
+
+func (s Storage) RecordEvents(ctx context.Context, events []storage.Event) error {
+	return observe(ctx, "RecordEvents", func(ctx context.Context) error {
+		return s.next.RecordEvents(ctx, events)
+	})
+}
+
+func (s Storage) ClaimIdempotencyKey(ctx context.Context, key, fingerprint string, window, lease time.Duration) (storage.IdempotentResponse, bool, error) {
+	var resp storage.IdempotentResponse
+	var ok bool
+	err := observe(ctx, "ClaimIdempotencyKey", func(ctx context.Context) (err error) {
+		resp, ok, err = s.next.ClaimIdempotencyKey(ctx, key, fingerprint, window, lease)
+		return err
+	})
+
+	return resp, ok, err
+}
+
+func (s Storage) SaveIdempotentResponse(ctx context.Context, key string, resp storage.IdempotentResponse) error {
+	return observe(ctx, "SaveIdempotentResponse", func(ctx context.Context) error {
+		return s.next.SaveIdempotentResponse(ctx, key, resp)
+	})
+}
+
+func (s Storage) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	return observe(ctx, "DeleteIdempotencyKey", func(ctx context.Context) error {
+		return s.next.DeleteIdempotencyKey(ctx, key)
+	})
+}
+
+func (s Storage) CreateAPIKey(ctx context.Context, key storage.APIKey, keyHash string) (int, error) {
+	return observeResult(ctx, "CreateAPIKey", func(ctx context.Context) (int, error) {
+		return s.next.CreateAPIKey(ctx, key, keyHash)
+	})
+}
+
+func (s Storage) GetAPIKey(ctx context.Context, keyHash string) (storage.APIKey, error) {
+	return observeResult(ctx, "GetAPIKey", func(ctx context.Context) (storage.APIKey, error) {
+		return s.next.GetAPIKey(ctx, keyHash)
+	})
+}
+
+func (s Storage) ListAPIKeys(ctx context.Context) ([]storage.APIKey, error) {
+	return observeResult(ctx, "ListAPIKeys", func(ctx context.Context) ([]storage.APIKey, error) {
+		return s.next.ListAPIKeys(ctx)
+	})
+}
+
+func (s Storage) RevokeAPIKey(ctx context.Context, keyID int) error {
+	return observe(ctx, "RevokeAPIKey", func(ctx context.Context) error {
+		return s.next.RevokeAPIKey(ctx, keyID)
+	})
+}
+
+func (s Storage) SaveInvalidClicks(ctx context.Context, clicks []storage.InvalidClick) error {
+	return observe(ctx, "SaveInvalidClicks", func(ctx context.Context) error {
+		return s.next.SaveInvalidClicks(ctx, clicks)
+	})
+}
@@ -0,0 +1,122 @@
+package instrumented
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+	"github.com/otus-murashko/banners-rotation/internal/metrics"
+	"github.com/otus-murashko/banners-rotation/internal/storage"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// impressionStorage returns the impression or the error it is set up with.
+type impressionStorage struct {
+	storage.Storage
+	impression storage.Impression
+	err        error
+}
+
+func (s impressionStorage) GetImpression(context.Context, int64) (storage.Impression, error) {
+	return s.impression, s.err
+}
+
+// storageMetric returns the number of the GetImpression calls and of its
+// errors of the kind recorded in the metrics.
+func storageMetric(t *testing.T, kind apperror.Kind) (calls, errs uint64) {
+	t.Helper()
+
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["operation"] != "GetImpression" {
+				continue
+			}
+
+			switch family.GetName() {
+			case "banners_rotation_storage_query_duration_seconds":
+				calls = m.GetHistogram().GetSampleCount()
+			case "banners_rotation_storage_errors_total":
+				if labels["kind"] == string(kind) {
+					errs = uint64(m.GetCounter().GetValue())
+				}
+			}
+		}
+	}
+	return calls, errs
+}
+
+func TestObserve(t *testing.T) {
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	tests := []struct {
+		name       string
+		next       impressionStorage
+		wantKind   apperror.Kind
+		wantErrors uint64
+		wantStatus codes.Code
+	}{
+		{
+			name: "found",
+			next: impressionStorage{impression: storage.Impression{ID: 7}},
+		},
+		{
+			name:     "not found is expected",
+			next:     impressionStorage{err: apperror.NotFound("impression 7 not found")},
+			wantKind: apperror.KindNotFound,
+		},
+		{
+			name:       "failure",
+			next:       impressionStorage{err: errors.New("connection reset")},
+			wantKind:   apperror.KindInternal,
+			wantErrors: 1,
+			wantStatus: codes.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+			callsBefore, errorsBefore := storageMetric(t, tt.wantKind)
+
+			impression, err := New(tt.next).GetImpression(context.Background(), 7)
+			if !errors.Is(err, tt.next.err) || impression != tt.next.impression {
+				t.Errorf("GetImpression() = %+v, %v, want %+v, %v", impression, err, tt.next.impression, tt.next.err)
+			}
+
+			calls, errs := storageMetric(t, tt.wantKind)
+			if calls-callsBefore != 1 {
+				t.Errorf("recorded calls = %d, want 1", calls-callsBefore)
+			}
+			if tt.wantKind != "" && errs-errorsBefore != tt.wantErrors {
+				t.Errorf("recorded %s errors = %d, want %d", tt.wantKind, errs-errorsBefore, tt.wantErrors)
+			}
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("ended spans = %d, want 1", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != "storage.GetImpression" || span.SpanKind() != trace.SpanKindClient {
+				t.Errorf("span = %s of kind %s, want storage.GetImpression of kind client", span.Name(), span.SpanKind())
+			}
+			if span.Status().Code != tt.wantStatus {
+				t.Errorf("span status = %s, want %s", span.Status().Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package psql
+
+import (
+	"context"
+	dbsql "database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx"
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+)
+
+// PostgreSQL error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgNotNullViolation    = "23502"
+	pgCheckViolation      = "23514"
+	pgInvalidText         = "22P02"
+	pgConnectionClass     = "08"
+)
+
+// wrapError converts driver errors to domain errors so that SQL details never
+// reach API clients.
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return err
+	}
+
+	if errors.Is(err, dbsql.ErrNoRows) {
+		return apperror.Wrap(apperror.KindNotFound, err, "entity not found")
+	}
+
+	var pgErr pgx.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == pgUniqueViolation:
+			return apperror.Wrap(apperror.KindConflict, err, "entity already exists")
+		case pgErr.Code == pgForeignKeyViolation:
+			return apperror.Wrap(apperror.KindValidation, err, "referenced entity does not exist")
+		case pgErr.Code == pgNotNullViolation, pgErr.Code == pgCheckViolation, pgErr.Code == pgInvalidText:
+			return apperror.Wrap(apperror.KindValidation, err, "invalid value")
+		case strings.HasPrefix(pgErr.Code, pgConnectionClass):
+			return apperror.Wrap(apperror.KindUnavailable, err, "database is unavailable")
+		}
+		return err
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, pgx.ErrDeadConn) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) ||
+		errors.Is(err, dbsql.ErrConnDone) {
+		return apperror.Wrap(apperror.KindUnavailable, err, "database is unavailable")
+	}
+
+	return err
+}
@@ -2,13 +2,19 @@ package psql
 
 import (
 	"context"
+	dbsql "database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	_ "github.com/jackc/pgx/stdlib"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
 	"github.com/otus-murashko/banners-rotation/internal/storage"
+	"github.com/otus-murashko/banners-rotation/internal/tenant"
 )
 
 type Storage struct {
@@ -31,12 +37,24 @@ func New(psqlInfo StorageInfo) *Storage {
 func (s *Storage) Connect() error {
 	db, err := sqlx.Open("pgx", getPsqlString(s.info))
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 	s.db = db
 	return nil
 }
 
+// Ping checks that the database is reachable, sqlx.Open does not connect.
+func (s *Storage) Ping(ctx context.Context) error {
+	if s.db == nil {
+		return apperror.Unavailable("database is not connected")
+	}
+
+	if err := s.db.PingContext(ctx); err != nil {
+		return apperror.Wrap(apperror.KindUnavailable, err, "database is unavailable")
+	}
+	return nil
+}
+
 func (s *Storage) Close() error {
 	s.db.Close()
 	return nil
@@ -46,11 +64,11 @@ func (s *Storage) GetBannersBySlot(ctx context.Context, slotID int) ([]int, erro
 
 	sql := `SELECT banner
 	FROM rotation 
-	WHERE slot = $1`
+	WHERE tenant = $1 AND slot = $2`
 
-	rows, err := s.db.QueryxContext(ctx, sql, slotID)
+	rows, err := s.db.QueryxContext(ctx, sql, tenant.FromContext(ctx), slotID)
 	if err != nil {
-		return nil, err
+		return nil, wrapError(err)
 	}
 	defer rows.Close()
 
@@ -72,15 +90,13 @@ func (s *Storage) GetBannersBySlot(ctx context.Context, slotID int) ([]int, erro
 
 func (s *Storage) GetBannersStat(ctx context.Context, slotID int, groupID int, bannerIDs []int) ([]storage.Statistic, error) {
 
-	sql := `SELECT banner, slot, clicks, shows, s_group
+	sql := `SELECT banner, slot, clicks, shows, s_group, conversions, conv_value
 	FROM statistic 
-	WHERE slot = $1 AND s_group = $2 AND banner = any($3)`
+	WHERE tenant = $1 AND slot = $2 AND s_group = $3 AND banner = any($4)`
 
-	rows, err := s.db.QueryxContext(ctx, sql, slotID, groupID, pq.Array(bannerIDs))
+	rows, err := s.db.QueryxContext(ctx, sql, tenant.FromContext(ctx), slotID, groupID, pq.Array(bannerIDs))
 	if err != nil {
-
-		fmt.Println("EEEEE", err)
-		return []storage.Statistic{}, err
+		return []storage.Statistic{}, wrapError(err)
 	}
 	defer rows.Close()
 
@@ -104,49 +120,144 @@ func (s *Storage) UpdateShowStat(ctx context.Context, stat storage.Statistic) er
 
 	sql := `UPDATE statistic SET 
 			shows = shows + 1
- 			where banner = $1 AND slot = $2 AND s_group = $3;`
+ 			where banner = $1 AND slot = $2 AND s_group = $3 AND tenant = $4;`
 
-	_, err := s.db.ExecContext(ctx, sql, stat.BannerID, stat.SlotID, stat.SosialGroupID)
+	_, err := s.db.ExecContext(ctx, sql, stat.BannerID, stat.SlotID, stat.SosialGroupID, tenant.FromContext(ctx))
 
-	return err
+	return wrapError(err)
 }
 
+// UpdateClickStat counts the click in one transaction: in the banner
+// statistic, on the impression when it is set and in the experiment arm when
+// it is set. Only an arm of the experiment running in the slot is counted.
 func (s *Storage) UpdateClickStat(ctx context.Context, stat storage.Statistic) error {
 
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return wrapError(err)
+	}
+	defer tx.Rollback()
+
+	tenantID := tenant.FromContext(ctx)
+
 	sql := `UPDATE statistic SET 
 			clicks = clicks + 1
- 			where banner = $1 AND slot = $2 AND s_group = $3;`
+ 			where banner = $1 AND slot = $2 AND s_group = $3 AND tenant = $4;`
+
+	if _, err = tx.ExecContext(ctx, sql, stat.BannerID, stat.SlotID, stat.SosialGroupID, tenantID); err != nil {
+		return wrapError(err)
+	}
+
+	if stat.ImpressionID != 0 {
+		sql = `UPDATE impression SET
+				clicked_at = now()
+				WHERE id = $1 AND tenant = $2 AND clicked_at IS NULL`
+
+		if _, err = tx.ExecContext(ctx, sql, stat.ImpressionID, tenantID); err != nil {
+			return wrapError(err)
+		}
+	}
+
+	if stat.ExperimentID != 0 {
+		sql = `INSERT INTO experiment_statistic(experiment, arm, clicks)
+				SELECT a.experiment, a.name, 1 FROM experiment_arm a
+				JOIN experiment e ON e.id = a.experiment
+				WHERE a.experiment = $1 AND a.name = $2 AND e.slot = $3 AND e.tenant = $4
+				ON CONFLICT (experiment, arm) DO UPDATE SET
+				clicks = experiment_statistic.clicks + 1`
+
+		if _, err = tx.ExecContext(ctx, sql, stat.ExperimentID, stat.Arm, stat.SlotID, tenantID); err != nil {
+			return wrapError(err)
+		}
+	}
+
+	return wrapError(tx.Commit())
+}
+
+type rotationRow struct {
+	BannerID  int            `db:"banner"`
+	SlotID    int            `db:"slot"`
+	Countries pq.StringArray `db:"countries"`
+	Regions   pq.StringArray `db:"regions"`
+	Devices   pq.StringArray `db:"devices"`
+	OS        pq.StringArray `db:"os"`
+	Languages pq.StringArray `db:"languages"`
+}
+
+func (s *Storage) GetSlotRotations(ctx context.Context, slotID int) ([]storage.Rotation, error) {
+
+	sql := `SELECT banner, slot, countries, regions, devices, os, languages
+	FROM rotation
+	WHERE tenant = $1 AND slot = $2`
+
+	rows := make([]rotationRow, 0)
+	if err := s.db.SelectContext(ctx, &rows, sql, tenant.FromContext(ctx), slotID); err != nil {
+		return nil, wrapError(err)
+	}
 
-	_, err := s.db.ExecContext(ctx, sql, stat.BannerID, stat.SlotID, stat.SosialGroupID)
+	rotations := make([]storage.Rotation, 0, len(rows))
+	for _, row := range rows {
+		rotations = append(rotations, storage.Rotation{
+			BannerID: row.BannerID,
+			SlotID:   row.SlotID,
+			Targeting: storage.Targeting{
+				Countries: row.Countries,
+				Regions:   row.Regions,
+				Devices:   row.Devices,
+				OS:        row.OS,
+				Languages: row.Languages,
+			},
+		})
+	}
 
-	return err
+	return rotations, nil
 }
 
-func (s *Storage) AddBannerToSlot(ctx context.Context, bannerID int, slotID int) error {
+func (s *Storage) AddBannerToSlot(ctx context.Context, bannerID int, slotID int, targeting storage.Targeting) error {
 
-	tx, err := s.db.Begin()
+	tx, err := s.db.BeginTxx(ctx, nil)
 
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
+	defer tx.Rollback()
 
-	sql := `INSERT INTO rotation(banner, slot)
-		 	VALUES($1, $2) ON CONFLICT (banner, slot) DO NOTHING `
+	tenantID := tenant.FromContext(ctx)
 
-	// Insert to Slot
-	_, err = s.db.ExecContext(ctx, sql, bannerID, slotID)
+	// the banner and the slot must be of the same tenant
+	sql := `INSERT INTO rotation(banner, slot, countries, regions, devices, os, languages, tenant)
+		 	SELECT $1, $2, $3, $4, $5, $6, $7, $8
+		 	WHERE EXISTS(SELECT 1 FROM banner WHERE id = $1 AND tenant = $8)
+		 	AND EXISTS(SELECT 1 FROM slot WHERE id = $2 AND tenant = $8)
+		 	ON CONFLICT (banner, slot) DO UPDATE SET
+		 	countries = EXCLUDED.countries, regions = EXCLUDED.regions, devices = EXCLUDED.devices,
+		 	os = EXCLUDED.os, languages = EXCLUDED.languages
+		 	WHERE rotation.tenant = EXCLUDED.tenant`
+
+	// Insert to Slot or update the targeting of the existing entry
+	res, err := tx.ExecContext(ctx, sql, bannerID, slotID,
+		stringArray(targeting.Countries), stringArray(targeting.Regions), stringArray(targeting.Devices),
+		stringArray(targeting.OS), stringArray(targeting.Languages), tenantID)
 
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
-	// Get all social groups
+	if added, err := res.RowsAffected(); err != nil || added == 0 {
+		if err != nil {
+			return wrapError(err)
+		}
+		return apperror.NotFound("banner %d or slot %d not found", bannerID, slotID)
+	}
+
+	// Get all social groups of the tenant
 
 	sql = `SELECT id
-		   FROM social_group`
-	rows, err := s.db.QueryxContext(ctx, sql)
+		   FROM social_group
+		   WHERE tenant = $1`
+	rows, err := tx.QueryxContext(ctx, sql, tenantID)
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 	defer rows.Close()
 
@@ -163,31 +274,36 @@ func (s *Storage) AddBannerToSlot(ctx context.Context, bannerID int, slotID int)
 
 		groupIDs = append(groupIDs, groupID)
 	}
+	// the transaction connection must be released before the next statement
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return wrapError(err)
+	}
 
 	if len(errorsStr) > 0 {
 		return getQueryError(errorsStr)
 	}
 
 	if len(groupIDs) == 0 {
-		return fmt.Errorf("no groups created in DB")
+		return apperror.Conflict("no social groups created yet")
 	}
 
-	sql = "INSERT INTO statistic(banner, slot, s_group) VALUES " +
+	sql = "INSERT INTO statistic(banner, slot, tenant, s_group) VALUES " +
 		createInsertStatValues(groupIDs) +
 		"ON CONFLICT (banner, slot, s_group) DO NOTHING "
 
-	fmt.Println(sql)
 	// Create empty statistic for all sosial groups
 
-	_, err = s.db.ExecContext(ctx, sql, bannerID, slotID)
+	_, err = tx.ExecContext(ctx, sql, bannerID, slotID, tenantID)
 
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	err = tx.Commit()
 
-	return err
+	return wrapError(err)
 
 }
 
@@ -196,7 +312,7 @@ func createInsertStatValues(groupIDs []int) string {
 
 	for i, groupID := range groupIDs {
 
-		sb.Write([]byte(fmt.Sprintf("($1, $2, %d)", groupID)))
+		sb.Write([]byte(fmt.Sprintf("($1, $2, $3, %d)", groupID)))
 		if i < len(groupIDs)-1 {
 			sb.Write([]byte(", "))
 		}
@@ -207,19 +323,38 @@ func createInsertStatValues(groupIDs []int) string {
 
 func (s *Storage) DeleteBannerFromSlot(ctx context.Context, bannerID int, slotID int) error {
 
-	sql := `DELETE from rotation where banner = $1 and slot = $2;`
+	sql := `DELETE from rotation where banner = $1 and slot = $2 and tenant = $3;`
 
-	_, err := s.db.ExecContext(ctx, sql, bannerID, slotID)
+	_, err := s.db.ExecContext(ctx, sql, bannerID, slotID, tenant.FromContext(ctx))
 
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
-	return err
+	return wrapError(err)
+}
+
+func (s *Storage) CreateBanner(ctx context.Context, banner storage.Banner) (int, error) {
+
+	sql := `INSERT INTO banner(descr, pricing, bid, tenant)
+			VALUES($1, $2, $3, $4) RETURNING id`
+
+	bannerID := 0
+	err := s.db.QueryRowxContext(ctx, sql, banner.Descr, banner.Pricing, banner.Bid, tenant.FromContext(ctx)).Scan(&bannerID)
+
+	return bannerID, wrapError(err)
 }
 
-func (s *Storage) CreateBanner(ctx context.Context, desc string) (int, error) {
-	return createInstance(ctx, s.db, "banner", desc)
+func (s *Storage) GetBanners(ctx context.Context, bannerIDs []int) ([]storage.Banner, error) {
+
+	sql := `SELECT id, descr, pricing, bid
+	FROM banner
+	WHERE tenant = $1 AND id = any($2)`
+
+	banners := make([]storage.Banner, 0, len(bannerIDs))
+	err := s.db.SelectContext(ctx, &banners, sql, tenant.FromContext(ctx), pq.Array(bannerIDs))
+
+	return banners, wrapError(err)
 }
 
 func (s *Storage) CreateSlot(ctx context.Context, desc string) (int, error) {
@@ -230,21 +365,51 @@ func (s *Storage) CreateGroup(ctx context.Context, desc string) (int, error) {
 	return createInstance(ctx, s.db, "social_group", desc)
 }
 
+func (s *Storage) BannerExists(ctx context.Context, bannerID int) (bool, error) {
+	return instanceExists(ctx, s.db, "banner", bannerID)
+}
+
+func (s *Storage) SlotExists(ctx context.Context, slotID int) (bool, error) {
+	return instanceExists(ctx, s.db, "slot", slotID)
+}
+
+func (s *Storage) GroupExists(ctx context.Context, groupID int) (bool, error) {
+	return instanceExists(ctx, s.db, "social_group", groupID)
+}
+
+func instanceExists(ctx context.Context, db *sqlx.DB, tName string, id int) (bool, error) {
+
+	sql := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1 AND tenant = $2)", tName)
+
+	exists := false
+	err := db.QueryRowContext(ctx, sql, id, tenant.FromContext(ctx)).Scan(&exists)
+
+	return exists, wrapError(err)
+}
+
 func createInstance(ctx context.Context, db *sqlx.DB, tNmae, desc string) (int, error) {
 
-	sql := fmt.Sprintf("INSERT INTO %s(descr) VALUES($1) RETURNING id", tNmae)
+	sql := fmt.Sprintf("INSERT INTO %s(descr, tenant) VALUES($1, $2) RETURNING id", tNmae)
 
 	lastInsertID := 0
 
-	row := db.QueryRowContext(ctx, sql, desc)
+	row := db.QueryRowContext(ctx, sql, desc, tenant.FromContext(ctx))
 
 	if row.Err() != nil {
-		return 0, row.Err()
+		return 0, wrapError(row.Err())
 	}
 
 	err := row.Scan(&lastInsertID)
 
-	return lastInsertID, err
+	return lastInsertID, wrapError(err)
+}
+
+// stringArray keeps NULL out of the NOT NULL targeting columns.
+func stringArray(values []string) pq.StringArray {
+	if values == nil {
+		return pq.StringArray{}
+	}
+	return pq.StringArray(values)
 }
 
 func getPsqlString(dbConfig StorageInfo) string {
@@ -259,3 +424,622 @@ func getQueryError(errorsStr []string) error {
 
 	return fmt.Errorf("get banners error: , %v", strings.Join(errorsStr, ";"))
 }
+
+func (s *Storage) CreateExperiment(ctx context.Context, exp storage.Experiment) (int, error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, wrapError(err)
+	}
+	defer tx.Rollback()
+
+	tenantID := tenant.FromContext(ctx)
+
+	// Only one experiment may run on a slot
+	sql := `UPDATE experiment SET active = false WHERE slot = $1 AND tenant = $2 AND active`
+
+	if _, err = tx.ExecContext(ctx, sql, exp.SlotID, tenantID); err != nil {
+		return 0, wrapError(err)
+	}
+
+	sql = `INSERT INTO experiment(slot, descr, active, tenant)
+		   SELECT $1, $2, true, $3
+		   WHERE EXISTS(SELECT 1 FROM slot WHERE id = $1 AND tenant = $3)
+		   RETURNING id`
+
+	experimentID := 0
+	err = tx.QueryRowxContext(ctx, sql, exp.SlotID, exp.Descr, tenantID).Scan(&experimentID)
+	if errors.Is(err, dbsql.ErrNoRows) {
+		return 0, apperror.NotFound("slot %d not found", exp.SlotID)
+	}
+	if err != nil {
+		return 0, wrapError(err)
+	}
+
+	sql = `INSERT INTO experiment_arm(experiment, name, strategy, weight)
+		   VALUES($1, $2, $3, $4)`
+
+	for _, arm := range exp.Arms {
+		if _, err = tx.ExecContext(ctx, sql, experimentID, arm.Name, arm.Strategy, arm.Weight); err != nil {
+			return 0, wrapError(err)
+		}
+	}
+
+	return experimentID, wrapError(tx.Commit())
+}
+
+func (s *Storage) GetExperiment(ctx context.Context, experimentID int) (storage.Experiment, error) {
+
+	sql := `SELECT id, slot, descr, active
+	FROM experiment
+	WHERE id = $1 AND tenant = $2`
+
+	exp, err := s.getExperiment(ctx, sql, experimentID, tenant.FromContext(ctx))
+	if errors.Is(err, dbsql.ErrNoRows) {
+		return storage.Experiment{}, apperror.NotFound("experiment %d not found", experimentID)
+	}
+
+	return exp, err
+}
+
+func (s *Storage) GetSlotExperiment(ctx context.Context, slotID int) (storage.Experiment, error) {
+
+	sql := `SELECT id, slot, descr, active
+	FROM experiment
+	WHERE slot = $1 AND tenant = $2 AND active`
+
+	exp, err := s.getExperiment(ctx, sql, slotID, tenant.FromContext(ctx))
+	if errors.Is(err, dbsql.ErrNoRows) {
+		return storage.Experiment{}, nil
+	}
+
+	return exp, wrapError(err)
+}
+
+func (s *Storage) getExperiment(ctx context.Context, sql string, args ...any) (storage.Experiment, error) {
+
+	var exp storage.Experiment
+	if err := s.db.QueryRowxContext(ctx, sql, args...).StructScan(&exp); err != nil {
+		return storage.Experiment{}, wrapError(err)
+	}
+
+	sql = `SELECT name, strategy, weight
+	FROM experiment_arm
+	WHERE experiment = $1
+	ORDER BY name`
+
+	if err := s.db.SelectContext(ctx, &exp.Arms, sql, exp.ID); err != nil {
+		return storage.Experiment{}, wrapError(err)
+	}
+
+	return exp, nil
+}
+
+func (s *Storage) GetExperimentStat(ctx context.Context, experimentID int) ([]storage.ArmStatistic, error) {
+
+	sql := `SELECT es.experiment, es.arm, es.clicks, es.shows
+	FROM experiment_statistic es
+	JOIN experiment e ON e.id = es.experiment
+	WHERE es.experiment = $1 AND e.tenant = $2`
+
+	stats := make([]storage.ArmStatistic, 0)
+	err := s.db.SelectContext(ctx, &stats, sql, experimentID, tenant.FromContext(ctx))
+
+	return stats, wrapError(err)
+}
+
+func (s *Storage) UpdateArmShowStat(ctx context.Context, stat storage.Statistic) error {
+
+	sql := `INSERT INTO experiment_statistic(experiment, arm, shows)
+			SELECT id, $2, 1 FROM experiment WHERE id = $1 AND tenant = $3
+			ON CONFLICT (experiment, arm) DO UPDATE SET
+			shows = experiment_statistic.shows + 1`
+
+	_, err := s.db.ExecContext(ctx, sql, stat.ExperimentID, stat.Arm, tenant.FromContext(ctx))
+
+	return wrapError(err)
+}
+
+func (s *Storage) CreateImpression(ctx context.Context, stat storage.Statistic) (int64, error) {
+
+	sql := `INSERT INTO impression(banner, slot, s_group, experiment, arm, tenant)
+			VALUES($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	var impressionID int64
+	err := s.db.QueryRowxContext(ctx, sql, stat.BannerID, stat.SlotID, stat.SosialGroupID,
+		stat.ExperimentID, stat.Arm, tenant.FromContext(ctx)).Scan(&impressionID)
+
+	return impressionID, wrapError(err)
+}
+
+func (s *Storage) GetImpression(ctx context.Context, impressionID int64) (storage.Impression, error) {
+
+	sql := `SELECT id, banner, slot, s_group, experiment, arm, shown_at, clicked_at
+	FROM impression
+	WHERE id = $1 AND tenant = $2`
+
+	var impression storage.Impression
+	err := s.db.QueryRowxContext(ctx, sql, impressionID, tenant.FromContext(ctx)).StructScan(&impression)
+	if errors.Is(err, dbsql.ErrNoRows) {
+		return storage.Impression{}, apperror.NotFound("impression %d not found", impressionID)
+	}
+
+	return impression, wrapError(err)
+}
+
+// GetImpressions reads the impressions in one query. The impressions not
+// found are missing from the result.
+func (s *Storage) GetImpressions(ctx context.Context, impressionIDs []int64) (map[int64]storage.Impression, error) {
+
+	sql := `SELECT id, banner, slot, s_group, experiment, arm, shown_at, clicked_at
+	FROM impression
+	WHERE id = any($1) AND tenant = $2`
+
+	rows := make([]storage.Impression, 0)
+	if err := s.db.SelectContext(ctx, &rows, sql, pq.Array(impressionIDs), tenant.FromContext(ctx)); err != nil {
+		return nil, wrapError(err)
+	}
+
+	impressions := make(map[int64]storage.Impression, len(rows))
+	for _, impression := range rows {
+		impressions[impression.ID] = impression
+	}
+
+	return impressions, nil
+}
+
+// AddConversion stores the conversion of an impression and adds it to the
+// banner statistic. It reports false when the impression already converted.
+func (s *Storage) AddConversion(ctx context.Context, conv storage.Conversion) (bool, error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, wrapError(err)
+	}
+	defer tx.Rollback()
+
+	tenantID := tenant.FromContext(ctx)
+
+	// the impression must be of the tenant
+	sql := `INSERT INTO conversion(impression, value)
+			SELECT id, $2 FROM impression WHERE id = $1 AND tenant = $3
+			ON CONFLICT (impression) DO NOTHING`
+
+	res, err := tx.ExecContext(ctx, sql, conv.ImpressionID, conv.Value, tenantID)
+	if err != nil {
+		return false, wrapError(err)
+	}
+
+	if inserted, err := res.RowsAffected(); err != nil || inserted == 0 {
+		return false, wrapError(err)
+	}
+
+	sql = `UPDATE statistic SET
+			conversions = statistic.conversions + 1,
+			conv_value = statistic.conv_value + $2
+			FROM impression i
+			WHERE i.id = $1 AND i.tenant = $3 AND statistic.tenant = i.tenant
+			AND statistic.banner = i.banner AND statistic.slot = i.slot AND statistic.s_group = i.s_group`
+
+	if _, err = tx.ExecContext(ctx, sql, conv.ImpressionID, conv.Value, tenantID); err != nil {
+		return false, wrapError(err)
+	}
+
+	return true, wrapError(tx.Commit())
+}
+
+func (s *Storage) GetRevenueStat(ctx context.Context, slotID int) ([]storage.RevenueStatistic, error) {
+
+	sql := `SELECT st.slot, st.banner, b.pricing, b.bid,
+	SUM(st.shows) AS shows, SUM(st.clicks) AS clicks, SUM(st.conversions) AS conversions
+	FROM statistic st
+	JOIN banner b ON b.id = st.banner AND b.tenant = st.tenant
+	WHERE st.tenant = $2 AND ($1 = 0 OR st.slot = $1)
+	GROUP BY st.slot, st.banner, b.pricing, b.bid
+	ORDER BY st.slot, st.banner`
+
+	stats := make([]storage.RevenueStatistic, 0)
+	err := s.db.SelectContext(ctx, &stats, sql, slotID, tenant.FromContext(ctx))
+
+	return stats, wrapError(err)
+}
+
+type slotBannerRow struct {
+	storage.Statistic
+	Countries       pq.StringArray `db:"countries"`
+	Regions         pq.StringArray `db:"regions"`
+	Devices         pq.StringArray `db:"devices"`
+	OS              pq.StringArray `db:"os"`
+	Languages       pq.StringArray `db:"languages"`
+	Descr           string         `db:"descr"`
+	Pricing         string         `db:"pricing"`
+	Bid             float64        `db:"bid"`
+	Experiment      int            `db:"experiment"`
+	ExperimentDescr string         `db:"experiment_descr"`
+	Arms            []byte         `db:"arms"`
+}
+
+// GetSlotsBanners reads the rotation, the banner and its statistic of all the
+// slots with the active experiment of the slot and its arms in one query.
+func (s *Storage) GetSlotsBanners(ctx context.Context, slotIDs []int, groupID int) ([]storage.SlotBanner, error) {
+
+	sql := `WITH experiments AS (
+		SELECT e.id, e.slot, e.descr,
+		json_agg(json_build_object('name', a.name, 'strategy', a.strategy, 'weight', a.weight) ORDER BY a.name) AS arms
+		FROM experiment e
+		JOIN experiment_arm a ON a.experiment = e.id
+		WHERE e.tenant = $3 AND e.active AND e.slot = any($1)
+		GROUP BY e.id, e.slot, e.descr
+	)
+	SELECT r.banner, r.slot, r.countries, r.regions, r.devices, r.os, r.languages,
+	st.clicks, st.shows, st.s_group, st.conversions, st.conv_value,
+	b.descr, b.pricing, b.bid,
+	COALESCE(x.id, 0) AS experiment, COALESCE(x.descr, '') AS experiment_descr, x.arms
+	FROM rotation r
+	JOIN statistic st ON st.banner = r.banner AND st.slot = r.slot AND st.s_group = $2 AND st.tenant = r.tenant
+	JOIN banner b ON b.id = r.banner AND b.tenant = r.tenant
+	LEFT JOIN experiments x ON x.slot = r.slot
+	WHERE r.tenant = $3 AND r.slot = any($1)`
+
+	rows := make([]slotBannerRow, 0)
+	if err := s.db.SelectContext(ctx, &rows, sql, pq.Array(slotIDs), groupID, tenant.FromContext(ctx)); err != nil {
+		return nil, wrapError(err)
+	}
+
+	experiments := make(map[int]storage.Experiment)
+	banners := make([]storage.SlotBanner, 0, len(rows))
+	for _, row := range rows {
+		exp, ok := experiments[row.SlotID]
+		if !ok && row.Experiment != 0 {
+			exp = storage.Experiment{ID: row.Experiment, SlotID: row.SlotID, Descr: row.ExperimentDescr, Active: true}
+			if err := json.Unmarshal(row.Arms, &exp.Arms); err != nil {
+				return nil, fmt.Errorf("experiment %d arms: %w", row.Experiment, err)
+			}
+			experiments[row.SlotID] = exp
+		}
+
+		banners = append(banners, storage.SlotBanner{
+			Banner: storage.Banner{
+				ID:      row.BannerID,
+				Descr:   row.Descr,
+				Pricing: row.Pricing,
+				Bid:     row.Bid,
+			},
+			Targeting: storage.Targeting{
+				Countries: row.Countries,
+				Regions:   row.Regions,
+				Devices:   row.Devices,
+				OS:        row.OS,
+				Languages: row.Languages,
+			},
+			Stat:       row.Statistic,
+			Experiment: exp,
+		})
+	}
+
+	return banners, nil
+}
+
+// RecordShows counts the shows, the shows of the experiment arms and creates
+// the impressions in one statement. Every show must be of a different slot,
+// the impression IDs are returned by slot.
+func (s *Storage) RecordShows(ctx context.Context, shows []storage.Statistic) (map[int]int64, error) {
+
+	banners := make([]int, 0, len(shows))
+	slots := make([]int, 0, len(shows))
+	groups := make([]int, 0, len(shows))
+	experiments := make([]int, 0, len(shows))
+	arms := make([]string, 0, len(shows))
+	for _, show := range shows {
+		banners = append(banners, show.BannerID)
+		slots = append(slots, show.SlotID)
+		groups = append(groups, show.SosialGroupID)
+		experiments = append(experiments, show.ExperimentID)
+		arms = append(arms, show.Arm)
+	}
+
+	sql := `WITH shows AS (
+		SELECT * FROM unnest($1::int[], $2::int[], $3::int[], $5::int[], $6::text[])
+		AS t(banner, slot, s_group, experiment, arm)
+	), counted AS (
+		UPDATE statistic st SET shows = st.shows + 1
+		FROM shows
+		WHERE st.tenant = $4 AND st.banner = shows.banner AND st.slot = shows.slot AND st.s_group = shows.s_group
+	), arms_counted AS (
+		INSERT INTO experiment_statistic(experiment, arm, shows)
+		SELECT e.id, shows.arm, 1 FROM shows
+		JOIN experiment e ON e.id = shows.experiment AND e.tenant = $4
+		ON CONFLICT (experiment, arm) DO UPDATE SET
+		shows = experiment_statistic.shows + 1
+	)
+	INSERT INTO impression(banner, slot, s_group, experiment, arm, tenant)
+	SELECT banner, slot, s_group, experiment, arm, $4 FROM shows
+	RETURNING slot, id`
+
+	rows, err := s.db.QueryxContext(ctx, sql, pq.Array(banners), pq.Array(slots), pq.Array(groups),
+		tenant.FromContext(ctx), pq.Array(experiments), pq.Array(arms))
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer rows.Close()
+
+	impressions := make(map[int]int64, len(shows))
+	for rows.Next() {
+		var slotID int
+		var impressionID int64
+
+		if err := rows.Scan(&slotID, &impressionID); err != nil {
+			return nil, wrapError(err)
+		}
+		impressions[slotID] = impressionID
+	}
+
+	return impressions, wrapError(rows.Err())
+}
+
+// RecordEvents applies the shows and clicks in one transaction. The events are
+// summed up per banner statistic and per experiment arm, events of banners
+// that are not in the slot rotation or of arms that are not of the slot
+// experiment are ignored.
+func (s *Storage) RecordEvents(ctx context.Context, events []storage.Event) error {
+
+	types := make([]string, 0, len(events))
+	banners := make([]int, 0, len(events))
+	slots := make([]int, 0, len(events))
+	groups := make([]int, 0, len(events))
+	experiments := make([]int, 0, len(events))
+	arms := make([]string, 0, len(events))
+	clicked := make([]int64, 0)
+	clickedBanners := make([]int, 0)
+	clickedSlots := make([]int, 0)
+	for _, e := range events {
+		types = append(types, e.Type)
+		banners = append(banners, e.BannerID)
+		slots = append(slots, e.SlotID)
+		groups = append(groups, e.SosialGroupID)
+		experiments = append(experiments, e.ExperimentID)
+		arms = append(arms, e.Arm)
+
+		if e.Type == storage.EventClick && e.ImpressionID != 0 {
+			clicked = append(clicked, e.ImpressionID)
+			clickedBanners = append(clickedBanners, e.BannerID)
+			clickedSlots = append(clickedSlots, e.SlotID)
+		}
+	}
+
+	tenantID := tenant.FromContext(ctx)
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return wrapError(err)
+	}
+	defer tx.Rollback()
+
+	sql := `UPDATE statistic st SET
+			shows = st.shows + e.shows, clicks = st.clicks + e.clicks
+			FROM (
+				SELECT banner, slot, s_group,
+				count(*) FILTER (WHERE type = 'show') AS shows,
+				count(*) FILTER (WHERE type = 'click') AS clicks
+				FROM unnest($1::text[], $2::int[], $3::int[], $4::int[]) AS t(type, banner, slot, s_group)
+				GROUP BY banner, slot, s_group
+			) e
+			WHERE st.tenant = $5 AND st.banner = e.banner AND st.slot = e.slot AND st.s_group = e.s_group`
+
+	_, err = tx.ExecContext(ctx, sql, pq.Array(types), pq.Array(banners), pq.Array(slots), pq.Array(groups),
+		tenantID)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	// only the arms of the experiment of the event slot are counted
+	sql = `INSERT INTO experiment_statistic(experiment, arm, shows, clicks)
+			SELECT t.experiment, t.arm,
+			count(*) FILTER (WHERE t.type = 'show'),
+			count(*) FILTER (WHERE t.type = 'click')
+			FROM unnest($1::text[], $2::int[], $3::text[], $4::int[]) AS t(type, experiment, arm, slot)
+			JOIN experiment e ON e.id = t.experiment AND e.slot = t.slot AND e.tenant = $5
+			JOIN experiment_arm a ON a.experiment = e.id AND a.name = t.arm
+			GROUP BY t.experiment, t.arm
+			ON CONFLICT (experiment, arm) DO UPDATE SET
+			shows = experiment_statistic.shows + EXCLUDED.shows,
+			clicks = experiment_statistic.clicks + EXCLUDED.clicks`
+
+	_, err = tx.ExecContext(ctx, sql, pq.Array(types), pq.Array(experiments), pq.Array(arms), pq.Array(slots),
+		tenantID)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if len(clicked) > 0 {
+		// only the impressions of the clicked banner and slot are attributed
+		sql = `UPDATE impression i SET
+				clicked_at = now()
+				FROM unnest($1::bigint[], $2::int[], $3::int[]) AS c(id, banner, slot)
+				WHERE i.id = c.id AND i.banner = c.banner AND i.slot = c.slot
+				AND i.tenant = $4 AND i.clicked_at IS NULL`
+
+		_, err = tx.ExecContext(ctx, sql, pq.Array(clicked), pq.Array(clickedBanners), pq.Array(clickedSlots),
+			tenantID)
+		if err != nil {
+			return wrapError(err)
+		}
+	}
+
+	return wrapError(tx.Commit())
+}
+
+// ClaimIdempotencyKey stores the key for a new request. A key older than the
+// window, or still in progress after the lease, is claimed again. When the
+// key is taken it reports false with the stored response.
+func (s *Storage) ClaimIdempotencyKey(ctx context.Context, key, fingerprint string,
+	window, lease time.Duration,
+) (storage.IdempotentResponse, bool, error) {
+
+	tenantID := tenant.FromContext(ctx)
+
+	sql := `INSERT INTO idempotency_key(key, fingerprint, tenant)
+			VALUES($1, $2, $4)
+			ON CONFLICT (tenant, key) DO UPDATE SET
+			fingerprint = EXCLUDED.fingerprint, status = 0, content_type = '', body = NULL, created_at = now()
+			WHERE idempotency_key.created_at < now() - make_interval(secs => $3)
+			OR (idempotency_key.status = 0 AND idempotency_key.created_at < now() - make_interval(secs => $5))
+			RETURNING key`
+
+	var claimed string
+	err := s.db.QueryRowxContext(ctx, sql, key, fingerprint, window.Seconds(), tenantID, lease.Seconds()).Scan(&claimed)
+	if err == nil {
+		return storage.IdempotentResponse{}, true, nil
+	}
+	if !errors.Is(err, dbsql.ErrNoRows) {
+		return storage.IdempotentResponse{}, false, wrapError(err)
+	}
+
+	sql = `SELECT fingerprint, status, content_type, body
+			FROM idempotency_key
+			WHERE tenant = $1 AND key = $2`
+
+	var resp storage.IdempotentResponse
+	err = s.db.QueryRowxContext(ctx, sql, tenantID, key).StructScan(&resp)
+
+	return resp, false, wrapError(err)
+}
+
+func (s *Storage) SaveIdempotentResponse(ctx context.Context, key string, resp storage.IdempotentResponse) error {
+
+	sql := `UPDATE idempotency_key SET
+			status = $2, content_type = $3, body = $4
+			WHERE key = $1 AND tenant = $5`
+
+	_, err := s.db.ExecContext(ctx, sql, key, resp.Status, resp.ContentType, resp.Body, tenant.FromContext(ctx))
+
+	return wrapError(err)
+}
+
+func (s *Storage) DeleteIdempotencyKey(ctx context.Context, key string) error {
+
+	sql := `DELETE FROM idempotency_key WHERE key = $1 AND tenant = $2`
+
+	_, err := s.db.ExecContext(ctx, sql, key, tenant.FromContext(ctx))
+
+	return wrapError(err)
+}
+
+type apiKeyRow struct {
+	ID        int            `db:"id"`
+	Name      string         `db:"name"`
+	Tenant    string         `db:"tenant"`
+	Scopes    pq.StringArray `db:"scopes"`
+	CreatedAt time.Time      `db:"created_at"`
+	RevokedAt *time.Time     `db:"revoked_at"`
+}
+
+func (row apiKeyRow) apiKey() storage.APIKey {
+	return storage.APIKey{
+		ID:        row.ID,
+		Name:      row.Name,
+		Tenant:    row.Tenant,
+		Scopes:    row.Scopes,
+		CreatedAt: row.CreatedAt,
+		RevokedAt: row.RevokedAt,
+	}
+}
+
+func (s *Storage) CreateAPIKey(ctx context.Context, key storage.APIKey, keyHash string) (int, error) {
+
+	sql := `INSERT INTO api_key(name, tenant, key_hash, scopes)
+			VALUES($1, $2, $3, $4) RETURNING id`
+
+	keyID := 0
+	err := s.db.QueryRowxContext(ctx, sql, key.Name, key.Tenant, keyHash, stringArray(key.Scopes)).Scan(&keyID)
+
+	return keyID, wrapError(err)
+}
+
+// GetAPIKey finds the key that is not revoked by its hash.
+func (s *Storage) GetAPIKey(ctx context.Context, keyHash string) (storage.APIKey, error) {
+
+	sql := `SELECT id, name, tenant, scopes, created_at, revoked_at
+			FROM api_key
+			WHERE key_hash = $1 AND revoked_at IS NULL`
+
+	var row apiKeyRow
+	if err := s.db.GetContext(ctx, &row, sql, keyHash); err != nil {
+		return storage.APIKey{}, wrapError(err)
+	}
+
+	return row.apiKey(), nil
+}
+
+func (s *Storage) ListAPIKeys(ctx context.Context) ([]storage.APIKey, error) {
+
+	sql := `SELECT id, name, tenant, scopes, created_at, revoked_at
+			FROM api_key
+			ORDER BY id`
+
+	rows := make([]apiKeyRow, 0)
+	if err := s.db.SelectContext(ctx, &rows, sql); err != nil {
+		return nil, wrapError(err)
+	}
+
+	keys := make([]storage.APIKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.apiKey())
+	}
+
+	return keys, nil
+}
+
+func (s *Storage) RevokeAPIKey(ctx context.Context, keyID int) error {
+
+	sql := `UPDATE api_key SET
+			revoked_at = now()
+			WHERE id = $1 AND revoked_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, sql, keyID)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	revoked, err := res.RowsAffected()
+	if err != nil {
+		return wrapError(err)
+	}
+	if revoked == 0 {
+		return apperror.NotFound("active API key %d not found", keyID)
+	}
+
+	return nil
+}
+
+// SaveInvalidClicks stores the clicks in one statement. The reasons are
+// passed joined, as the arrays of unnest must not be nested.
+func (s *Storage) SaveInvalidClicks(ctx context.Context, clicks []storage.InvalidClick) error {
+
+	banners := make([]int, 0, len(clicks))
+	slots := make([]int, 0, len(clicks))
+	groups := make([]int, 0, len(clicks))
+	impressions := make([]int64, 0, len(clicks))
+	ips := make([]string, 0, len(clicks))
+	userAgents := make([]string, 0, len(clicks))
+	reasons := make([]string, 0, len(clicks))
+	for _, click := range clicks {
+		banners = append(banners, click.Stat.BannerID)
+		slots = append(slots, click.Stat.SlotID)
+		groups = append(groups, click.Stat.SosialGroupID)
+		impressions = append(impressions, click.Stat.ImpressionID)
+		ips = append(ips, click.Client.IP)
+		userAgents = append(userAgents, click.Client.UserAgent)
+		reasons = append(reasons, strings.Join(click.Reasons, ","))
+	}
+
+	sql := `INSERT INTO invalid_click(tenant, banner, slot, s_group, impression, ip, user_agent, reasons)
+			SELECT $1, banner, slot, s_group, NULLIF(impression, 0), ip, user_agent, string_to_array(reasons, ',')
+			FROM unnest($2::int[], $3::int[], $4::int[], $5::bigint[], $6::text[], $7::text[], $8::text[])
+			AS t(banner, slot, s_group, impression, ip, user_agent, reasons)`
+
+	_, err := s.db.ExecContext(ctx, sql, tenant.FromContext(ctx), pq.Array(banners), pq.Array(slots),
+		pq.Array(groups), pq.Array(impressions), pq.Array(ips), pq.Array(userAgents), pq.Array(reasons))
+
+	return wrapError(err)
+}
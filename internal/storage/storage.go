@@ -1,24 +1,64 @@
 package storage
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Storage interface {
 	Connect() error
 	Close() error
+	Ping(ctx context.Context) error
 	GetBannersBySlot(ctx context.Context, slotID int) ([]int, error)
 	GetBannersStat(ctx context.Context, slotID int, groupID int, bannerIDs []int) ([]Statistic, error)
-	AddBannerToSlot(ctx context.Context, bannerID int, slotID int) error
+	GetSlotRotations(ctx context.Context, slotID int) ([]Rotation, error)
+	AddBannerToSlot(ctx context.Context, bannerID int, slotID int, targeting Targeting) error
 	DeleteBannerFromSlot(ctx context.Context, bannerID int, slotID int) error
-	CreateBanner(ctx context.Context, desc string) (int, error)
+	CreateBanner(ctx context.Context, banner Banner) (int, error)
+	GetBanners(ctx context.Context, bannerIDs []int) ([]Banner, error)
 	CreateSlot(ctx context.Context, desc string) (int, error)
 	CreateGroup(ctx context.Context, desc string) (int, error)
+	BannerExists(ctx context.Context, bannerID int) (bool, error)
+	SlotExists(ctx context.Context, slotID int) (bool, error)
+	GroupExists(ctx context.Context, groupID int) (bool, error)
 	UpdateShowStat(ctx context.Context, stat Statistic) error
 	UpdateClickStat(ctx context.Context, stat Statistic) error
+	CreateExperiment(ctx context.Context, exp Experiment) (int, error)
+	GetExperiment(ctx context.Context, experimentID int) (Experiment, error)
+	GetSlotExperiment(ctx context.Context, slotID int) (Experiment, error)
+	GetExperimentStat(ctx context.Context, experimentID int) ([]ArmStatistic, error)
+	UpdateArmShowStat(ctx context.Context, stat Statistic) error
+	CreateImpression(ctx context.Context, stat Statistic) (int64, error)
+	GetImpression(ctx context.Context, impressionID int64) (Impression, error)
+	GetImpressions(ctx context.Context, impressionIDs []int64) (map[int64]Impression, error)
+	AddConversion(ctx context.Context, conv Conversion) (bool, error)
+	GetRevenueStat(ctx context.Context, slotID int) ([]RevenueStatistic, error)
+	GetSlotsBanners(ctx context.Context, slotIDs []int, groupID int) ([]SlotBanner, error)
+	RecordShows(ctx context.Context, shows []Statistic) (map[int]int64, error)
+	RecordEvents(ctx context.Context, events []Event) error
+	ClaimIdempotencyKey(ctx context.Context, key, fingerprint string, window, lease time.Duration) (IdempotentResponse, bool, error)
+	SaveIdempotentResponse(ctx context.Context, key string, resp IdempotentResponse) error
+	DeleteIdempotencyKey(ctx context.Context, key string) error
+	CreateAPIKey(ctx context.Context, key APIKey, keyHash string) (int, error)
+	GetAPIKey(ctx context.Context, keyHash string) (APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID int) error
+	SaveInvalidClicks(ctx context.Context, clicks []InvalidClick) error
 }
 
+// Pricing models of a banner: the bid is paid per thousand shows, per click
+// or per conversion.
+const (
+	PricingCPM = "cpm"
+	PricingCPC = "cpc"
+	PricingCPA = "cpa"
+)
+
 type Banner struct {
-	ID    int    `db:"id"`
-	Descr string `db:"descr"`
+	ID      int     `db:"id"`
+	Descr   string  `db:"descr"`
+	Pricing string  `db:"pricing" json:",omitempty"`
+	Bid     float64 `db:"bid" json:",omitempty"`
 }
 
 type Slot struct {
@@ -32,14 +72,169 @@ type SosialGroup struct {
 }
 
 type Rotation struct {
-	BannerID int `db:"banner"`
-	SlotID   int `db:"slot"`
+	BannerID  int       `db:"banner"`
+	SlotID    int       `db:"slot"`
+	Targeting Targeting `db:"-"`
+}
+
+// Targeting restricts where a banner in a slot may run. Empty lists allow
+// any value.
+type Targeting struct {
+	Countries []string `json:",omitempty"`
+	Regions   []string `json:",omitempty"`
+	Devices   []string `json:",omitempty"`
+	OS        []string `json:",omitempty"`
+	Languages []string `json:",omitempty"`
+}
+
+// SlotBanner is a banner of the slot rotation with its statistic for one
+// social group. Experiment is the active experiment of the slot, it is zero
+// when the slot runs none.
+type SlotBanner struct {
+	Banner     Banner
+	Targeting  Targeting
+	Stat       Statistic
+	Experiment Experiment
 }
 
 type Statistic struct {
-	BannerID      int `db:"banner"`
-	SlotID        int `db:"slot"`
-	ClicksCount   int `db:"clicks"`
-	ShowsCount    int `db:"shows"`
-	SosialGroupID int `db:"s_group"`
+	BannerID        int     `db:"banner"`
+	SlotID          int     `db:"slot"`
+	ClicksCount     int     `db:"clicks"`
+	ShowsCount      int     `db:"shows"`
+	SosialGroupID   int     `db:"s_group"`
+	Conversions     int     `db:"conversions"`
+	ConversionValue float64 `db:"conv_value"`
+	ExperimentID    int     `db:"-" json:",omitempty"`
+	Arm             string  `db:"-" json:",omitempty"`
+	ImpressionID    int64   `db:"-" json:",omitempty"`
+}
+
+// BannerRotation is a banner selected for a slot. ExperimentID and Arm are
+// set when the slot runs an experiment and must be echoed back with the click.
+type BannerRotation struct {
+	Banner
+	ExperimentID int    `json:",omitempty"`
+	Arm          string `json:",omitempty"`
+	ImpressionID int64  `json:",omitempty"`
+}
+
+// Event types of the statistic ingestion.
+const (
+	EventShow  = "show"
+	EventClick = "click"
+)
+
+// Event is a show or a click collected outside of the service. ExperimentID,
+// Arm and ImpressionID are the values echoed back from the rotation. IP and
+// UserAgent are of the client that clicked, the fraud filters check them. At
+// is when the event happened, the time it is recorded when zero.
+type Event struct {
+	Type          string
+	BannerID      int
+	SlotID        int
+	SosialGroupID int
+	ExperimentID  int    `json:",omitempty"`
+	Arm           string `json:",omitempty"`
+	ImpressionID  int64  `json:",omitempty"`
+	IP            string `json:",omitempty"`
+	UserAgent     string `json:",omitempty"`
+	At            time.Time
+}
+
+// APIKey is a client credential of a tenant. The key itself is shown once on
+// creation, only its hash is stored.
+type APIKey struct {
+	ID        int
+	Name      string
+	Tenant    string
+	Scopes    []string
+	CreatedAt time.Time
+	RevokedAt *time.Time `json:",omitempty"`
+}
+
+// IdempotentResponse is the response stored for an Idempotency-Key. Status is
+// zero while the first request with the key is still running.
+type IdempotentResponse struct {
+	Fingerprint string `db:"fingerprint"`
+	Status      int    `db:"status"`
+	ContentType string `db:"content_type"`
+	Body        []byte `db:"body"`
+}
+
+// ClientInfo describes the client a request came from.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// InvalidClick is a click flagged by the fraud filters. It is kept for audit
+// and not counted in the statistic.
+type InvalidClick struct {
+	Stat    Statistic
+	Client  ClientInfo
+	Reasons []string
+}
+
+// RotationBatch asks for banners for several slots of one page. With Unique
+// set a banner is not returned for more than one slot.
+type RotationBatch struct {
+	SlotIDs []int
+	GroupID int
+	Unique  bool
+}
+
+type SlotRotation struct {
+	SlotID int
+	BannerRotation
+}
+
+// Impression is a single show of a banner, kept to attribute clicks and
+// conversions to it. ExperimentID and Arm are set when the show was served by
+// an experiment arm, the clicks of the impression are counted in that arm.
+type Impression struct {
+	ID            int64      `db:"id"`
+	BannerID      int        `db:"banner"`
+	SlotID        int        `db:"slot"`
+	SosialGroupID int        `db:"s_group"`
+	ExperimentID  int        `db:"experiment"`
+	Arm           string     `db:"arm"`
+	ShownAt       time.Time  `db:"shown_at"`
+	ClickedAt     *time.Time `db:"clicked_at"`
+}
+
+type Conversion struct {
+	ImpressionID int64
+	Value        float64
+}
+
+type Experiment struct {
+	ID     int             `db:"id"`
+	SlotID int             `db:"slot"`
+	Descr  string          `db:"descr"`
+	Active bool            `db:"active"`
+	Arms   []ExperimentArm `db:"-"`
+}
+
+type ExperimentArm struct {
+	Name     string `db:"name"`
+	Strategy string `db:"strategy"`
+	Weight   int    `db:"weight"`
+}
+
+type ArmStatistic struct {
+	ExperimentID int    `db:"experiment"`
+	Arm          string `db:"arm"`
+	ClicksCount  int    `db:"clicks"`
+	ShowsCount   int    `db:"shows"`
+}
+
+type RevenueStatistic struct {
+	SlotID      int     `db:"slot"`
+	BannerID    int     `db:"banner"`
+	Pricing     string  `db:"pricing"`
+	Bid         float64 `db:"bid"`
+	ShowsCount  int     `db:"shows"`
+	ClicksCount int     `db:"clicks"`
+	Conversions int     `db:"conversions"`
 }
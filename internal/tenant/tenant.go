@@ -0,0 +1,58 @@
+package tenant
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+)
+
+// Default owns the data of the requests without a tenant, including all the
+// data created before the tenants were introduced.
+const Default = "default"
+
+// Header names the tenant the client expects its API key to belong to.
+const Header = "X-Tenant-ID"
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+func Valid(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// Resolve returns the tenant of a request. The tenant of the API key wins,
+// a different requested tenant is refused. A request without a key belongs to
+// the default tenant, anybody could name another one, so that is refused too.
+func Resolve(keyTenant string, authenticated bool, requested string) (string, error) {
+	if requested != "" && !Valid(requested) {
+		return "", apperror.Validation("%s must be lowercase letters, digits, _ or - up to 63 characters", Header)
+	}
+
+	if authenticated {
+		if requested != "" && requested != keyTenant {
+			return "", apperror.PermissionDenied("API key does not belong to tenant %q", requested)
+		}
+		return keyTenant, nil
+	}
+
+	if requested != "" && requested != Default {
+		return "", apperror.PermissionDenied("tenant %q requires an API key", requested)
+	}
+
+	return Default, nil
+}
+
+type contextKey struct{}
+
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant of the request, the storage scopes all the
+// queries by it.
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(contextKey{}).(string); ok {
+		return id
+	}
+	return Default
+}
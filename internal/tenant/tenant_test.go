@@ -0,0 +1,78 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name          string
+		keyTenant     string
+		authenticated bool
+		requested     string
+		want          string
+		wantKind      apperror.Kind
+	}{
+		{name: "key tenant", keyTenant: "acme", authenticated: true, want: "acme"},
+		{name: "key tenant requested", keyTenant: "acme", authenticated: true, requested: "acme", want: "acme"},
+		{name: "other tenant of a key", keyTenant: "acme", authenticated: true, requested: "other", wantKind: apperror.KindPermissionDenied},
+		{name: "invalid tenant of a key", keyTenant: "acme", authenticated: true, requested: "Acme", wantKind: apperror.KindValidation},
+		{name: "without a key", want: Default},
+		{name: "default tenant without a key", requested: Default, want: Default},
+		{name: "other tenant without a key", requested: "acme", wantKind: apperror.KindPermissionDenied},
+		{name: "invalid tenant without a key", requested: "../acme", wantKind: apperror.KindValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(tt.keyTenant, tt.authenticated, tt.requested)
+			if tt.wantKind != "" {
+				if apperror.KindOf(err) != tt.wantKind {
+					t.Errorf("Resolve() error = %v, want kind %q", err, tt.wantKind)
+				}
+				return
+			}
+
+			if err != nil || got != tt.want {
+				t.Errorf("Resolve() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{id: "default", want: true},
+		{id: "acme-2_eu", want: true},
+		{id: "0", want: true},
+		{id: strings.Repeat("a", 63), want: true},
+		{id: strings.Repeat("a", 64)},
+		{id: ""},
+		{id: "-acme"},
+		{id: "Acme"},
+		{id: "acme corp"},
+	}
+
+	for _, tt := range tests {
+		if got := Valid(tt.id); got != tt.want {
+			t.Errorf("Valid(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != Default {
+		t.Errorf("FromContext() without a tenant = %q, want %q", got, Default)
+	}
+
+	if got := FromContext(WithTenant(context.Background(), "acme")); got != "acme" {
+		t.Errorf("FromContext() = %q, want acme", got)
+	}
+}
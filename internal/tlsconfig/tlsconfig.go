@@ -0,0 +1,157 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/config"
+)
+
+// checkInterval is how often the handshakes look for renewed files.
+const checkInterval = 10 * time.Second
+
+// Reloader serves the certificate and the client CAs of the config and loads
+// them again when the files change, so renewed certificates are picked up
+// without a restart.
+type Reloader struct {
+	conf config.TLS
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  []time.Time
+	lastCheck time.Time
+}
+
+// New loads the files of conf, it fails when they are missing or invalid.
+func New(conf config.TLS) (*Reloader, error) {
+	r := &Reloader{conf: conf}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads the files again. The loaded ones are kept when the new ones
+// are invalid.
+func (r *Reloader) Reload() error {
+	modTimes, err := r.stat()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.conf.CertFile, r.conf.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.conf.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.conf.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("load client CA: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return errors.New("load client CA: no certificates found in " + r.conf.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert, r.clientCAs, r.modTimes = &cert, clientCAs, modTimes
+	r.lastCheck = time.Now()
+
+	return nil
+}
+
+// Config returns the server config that takes the certificate and the
+// client CAs of every handshake from the reloader.
+func (r *Reloader) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: r.configForClient,
+	}
+}
+
+func (r *Reloader) configForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.reloadChanged()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	clientAuth := tls.NoClientCert
+	switch {
+	case r.clientCAs != nil && r.conf.RequireClientCert:
+		clientAuth = tls.RequireAndVerifyClientCert
+	case r.clientCAs != nil:
+		clientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{*r.cert},
+		ClientCAs:    r.clientCAs,
+		ClientAuth:   clientAuth,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}, nil
+}
+
+// reloadChanged reloads the files when their modification time changed
+// since the last check.
+func (r *Reloader) reloadChanged() {
+	r.mu.Lock()
+	if time.Since(r.lastCheck) < checkInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.lastCheck = time.Now()
+	loaded := r.modTimes
+	r.mu.Unlock()
+
+	modTimes, err := r.stat()
+	if err != nil || equalTimes(loaded, modTimes) {
+		return
+	}
+
+	if err := r.Reload(); err != nil {
+		slog.Error("failed to reload TLS certificate, keeping the loaded one", "error", err)
+		return
+	}
+	slog.Info("TLS certificate reloaded")
+}
+
+func (r *Reloader) stat() ([]time.Time, error) {
+	files := []string{r.conf.CertFile, r.conf.KeyFile}
+	if r.conf.ClientCAFile != "" {
+		files = append(files, r.conf.ClientCAFile)
+	}
+
+	modTimes := make([]time.Time, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		modTimes = append(modTimes, info.ModTime())
+	}
+	return modTimes, nil
+}
+
+func equalTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,226 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/otus-murashko/banners-rotation/internal/config"
+)
+
+// writeCert writes a self-signed certificate of the common name and its key
+// to cert.pem and key.pem in dir.
+func writeCert(t *testing.T, dir, commonName string) config.TLS {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := config.TLS{CertFile: filepath.Join(dir, "cert.pem"), KeyFile: filepath.Join(dir, "key.pem")}
+	writeFile(t, conf.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	writeFile(t, conf.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	return conf
+}
+
+func writeFile(t *testing.T, name string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// commonName is the subject of the certificate served to a client.
+func commonName(t *testing.T, r *Reloader) string {
+	t.Helper()
+
+	conf, err := r.Config().GetConfigForClient(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("GetConfigForClient() error = %v", err)
+	}
+	leaf, err := x509.ParseCertificate(conf.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(t *testing.T, conf *config.TLS)
+		wantErr bool
+	}{
+		{name: "certificate", modify: func(*testing.T, *config.TLS) {}},
+		{
+			name:   "client CA",
+			modify: func(_ *testing.T, conf *config.TLS) { conf.ClientCAFile = conf.CertFile },
+		},
+		{
+			name:    "missing certificate",
+			modify:  func(_ *testing.T, conf *config.TLS) { conf.CertFile += ".missing" },
+			wantErr: true,
+		},
+		{
+			name:    "key of another certificate",
+			modify:  func(t *testing.T, conf *config.TLS) { conf.KeyFile = writeCert(t, t.TempDir(), "other").KeyFile },
+			wantErr: true,
+		},
+		{
+			name:    "missing client CA",
+			modify:  func(_ *testing.T, conf *config.TLS) { conf.ClientCAFile = conf.CertFile + ".missing" },
+			wantErr: true,
+		},
+		{
+			name:    "client CA without certificates",
+			modify:  func(_ *testing.T, conf *config.TLS) { conf.ClientCAFile = conf.KeyFile },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := writeCert(t, t.TempDir(), "server")
+			tt.modify(t, &conf)
+
+			r, err := New(conf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && commonName(t, r) != "server" {
+				t.Errorf("served certificate %q, want server", commonName(t, r))
+			}
+		})
+	}
+}
+
+func TestConfigClientAuth(t *testing.T) {
+	tests := []struct {
+		name              string
+		clientCA          bool
+		requireClientCert bool
+		want              tls.ClientAuthType
+	}{
+		{name: "no client CA", want: tls.NoClientCert},
+		{name: "client CA", clientCA: true, want: tls.VerifyClientCertIfGiven},
+		{name: "client certificate required", clientCA: true, requireClientCert: true, want: tls.RequireAndVerifyClientCert},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := writeCert(t, t.TempDir(), "server")
+			if tt.clientCA {
+				conf.ClientCAFile = conf.CertFile
+			}
+			conf.RequireClientCert = tt.requireClientCert
+
+			r, err := New(conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			serverConf, err := r.Config().GetConfigForClient(&tls.ClientHelloInfo{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if serverConf.ClientAuth != tt.want {
+				t.Errorf("ClientAuth = %v, want %v", serverConf.ClientAuth, tt.want)
+			}
+			if (serverConf.ClientCAs != nil) != tt.clientCA {
+				t.Errorf("ClientCAs = %v, want client CAs %v", serverConf.ClientCAs, tt.clientCA)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	r, err := New(writeCert(t, dir, "old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeCert(t, dir, "new")
+	if err = r.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := commonName(t, r); got != "new" {
+		t.Errorf("served certificate %q after Reload(), want new", got)
+	}
+
+	writeFile(t, filepath.Join(dir, "cert.pem"), []byte("not a certificate"))
+	if err = r.Reload(); err == nil {
+		t.Error("Reload() of an invalid certificate succeeded")
+	}
+	if got := commonName(t, r); got != "new" {
+		t.Errorf("served certificate %q after a failed Reload(), want new", got)
+	}
+}
+
+func TestReloadChanged(t *testing.T) {
+	tests := []struct {
+		name      string
+		sinceLast time.Duration
+		modified  bool
+		want      string
+	}{
+		{name: "checked recently", sinceLast: time.Second, modified: true, want: "old"},
+		{name: "files not modified", sinceLast: checkInterval, want: "old"},
+		{name: "files modified", sinceLast: checkInterval, modified: true, want: "new"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			conf := writeCert(t, dir, "old")
+			r, err := New(conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the new files keep the old modification time unless modified
+			writeCert(t, dir, "new")
+			for i, file := range []string{conf.CertFile, conf.KeyFile} {
+				modTime := r.modTimes[i]
+				if tt.modified {
+					modTime = modTime.Add(time.Minute)
+				}
+				if err = os.Chtimes(file, modTime, modTime); err != nil {
+					t.Fatal(err)
+				}
+			}
+			r.lastCheck = time.Now().Add(-tt.sinceLast)
+
+			if got := commonName(t, r); got != tt.want {
+				t.Errorf("served certificate %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+)
+
+// Validator collects field-level errors. Only the first error of a field is kept.
+type Validator struct {
+	fields map[string]string
+}
+
+func New() *Validator {
+	return &Validator{fields: make(map[string]string)}
+}
+
+func (v *Validator) Check(ok bool, field, format string, args ...any) {
+	if ok {
+		return
+	}
+
+	if _, exists := v.fields[field]; !exists {
+		v.fields[field] = fmt.Sprintf(format, args...)
+	}
+}
+
+// FieldValid reports whether no error was collected for the field yet.
+func (v *Validator) FieldValid(field string) bool {
+	_, exists := v.fields[field]
+	return !exists
+}
+
+func (v *Validator) Err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+
+	return apperror.InvalidFields(v.fields)
+}
+
+func NotBlank(value string) bool {
+	return strings.TrimSpace(value) != ""
+}
+
+func NoBlankItems(values []string) bool {
+	for _, value := range values {
+		if !NotBlank(value) {
+			return false
+		}
+	}
+	return true
+}
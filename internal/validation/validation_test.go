@@ -0,0 +1,118 @@
+package validation
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/otus-murashko/banners-rotation/internal/apperror"
+)
+
+func TestValidator(t *testing.T) {
+	type check struct {
+		ok    bool
+		field string
+		msg   string
+	}
+
+	tests := []struct {
+		name       string
+		checks     []check
+		wantFields map[string]string
+	}{
+		{
+			name:   "no checks",
+			checks: nil,
+		},
+		{
+			name:   "all passed",
+			checks: []check{{true, "Descr", "must not be empty"}, {true, "Bid", "must not be negative"}},
+		},
+		{
+			name:       "failed fields",
+			checks:     []check{{false, "Descr", "must not be empty"}, {true, "Bid", "x"}, {false, "SlotID", "must be positive"}},
+			wantFields: map[string]string{"Descr": "must not be empty", "SlotID": "must be positive"},
+		},
+		{
+			name:       "first error of a field is kept",
+			checks:     []check{{false, "SlotIDs", "must be positive"}, {false, "SlotIDs", "must not contain duplicates"}},
+			wantFields: map[string]string{"SlotIDs": "must be positive"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := New()
+			for _, c := range tt.checks {
+				v.Check(c.ok, c.field, c.msg)
+			}
+
+			err := v.Err()
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("Err() = %v, want nil", err)
+				}
+				return
+			}
+
+			if apperror.KindOf(err) != apperror.KindValidation {
+				t.Errorf("Err() kind = %q, want %q", apperror.KindOf(err), apperror.KindValidation)
+			}
+			if got := apperror.FieldsOf(err); !maps.Equal(got, tt.wantFields) {
+				t.Errorf("Err() fields = %v, want %v", got, tt.wantFields)
+			}
+			for field := range tt.wantFields {
+				if v.FieldValid(field) {
+					t.Errorf("FieldValid(%q) = true after a failed check", field)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckFormatsMessage(t *testing.T) {
+	v := New()
+	v.Check(false, "SlotIDs", "must not contain more than %d slots", 50)
+
+	if got := apperror.FieldsOf(v.Err())["SlotIDs"]; got != "must not contain more than 50 slots" {
+		t.Errorf("message = %q", got)
+	}
+}
+
+func TestNotBlank(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{value: "", want: false},
+		{value: "  \t\n", want: false},
+		{value: "banner", want: true},
+		{value: " banner ", want: true},
+	}
+
+	for _, tt := range tests {
+		if got := NotBlank(tt.value); got != tt.want {
+			t.Errorf("NotBlank(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestNoBlankItems(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   bool
+	}{
+		{name: "nil", want: true},
+		{name: "filled", values: []string{"RU", "BY"}, want: true},
+		{name: "empty item", values: []string{"RU", ""}, want: false},
+		{name: "blank item", values: []string{" "}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NoBlankItems(tt.values); got != tt.want {
+				t.Errorf("NoBlankItems(%q) = %v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS experiment (
+  id SERIAL PRIMARY KEY,
+  slot INTEGER NOT NULL REFERENCES slot(id),
+  descr TEXT,
+  active BOOLEAN NOT NULL DEFAULT true
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS experiment_active_slot_idx ON experiment(slot) WHERE active;
+
+CREATE TABLE IF NOT EXISTS experiment_arm (
+  experiment INTEGER NOT NULL REFERENCES experiment(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  strategy TEXT NOT NULL,
+  weight INTEGER NOT NULL DEFAULT 1,
+  PRIMARY KEY (experiment, name)
+);
+
+CREATE TABLE IF NOT EXISTS experiment_statistic (
+  experiment INTEGER NOT NULL,
+  arm TEXT NOT NULL,
+  shows INTEGER NOT NULL DEFAULT 0,
+  clicks INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY (experiment, arm),
+  FOREIGN KEY (experiment, arm) REFERENCES experiment_arm(experiment, name) ON DELETE CASCADE
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS experiment_statistic;
+DROP TABLE IF EXISTS experiment_arm;
+DROP TABLE IF EXISTS experiment;
+
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE rotation
+  ADD COLUMN IF NOT EXISTS countries TEXT[] NOT NULL DEFAULT '{}',
+  ADD COLUMN IF NOT EXISTS regions TEXT[] NOT NULL DEFAULT '{}',
+  ADD COLUMN IF NOT EXISTS devices TEXT[] NOT NULL DEFAULT '{}',
+  ADD COLUMN IF NOT EXISTS os TEXT[] NOT NULL DEFAULT '{}',
+  ADD COLUMN IF NOT EXISTS languages TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE rotation
+  DROP COLUMN IF EXISTS countries,
+  DROP COLUMN IF EXISTS regions,
+  DROP COLUMN IF EXISTS devices,
+  DROP COLUMN IF EXISTS os,
+  DROP COLUMN IF EXISTS languages;
+
+-- +goose StatementEnd
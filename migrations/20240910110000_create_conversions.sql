@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS impression (
+  id BIGSERIAL PRIMARY KEY,
+  banner INTEGER NOT NULL,
+  slot INTEGER NOT NULL,
+  s_group INTEGER NOT NULL,
+  experiment INTEGER NOT NULL DEFAULT 0,
+  arm TEXT NOT NULL DEFAULT '',
+  shown_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  clicked_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS conversion (
+  impression BIGINT PRIMARY KEY REFERENCES impression(id),
+  value DOUBLE PRECISION NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE statistic
+  ADD COLUMN IF NOT EXISTS conversions INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS conv_value DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE statistic
+  DROP COLUMN IF EXISTS conversions,
+  DROP COLUMN IF EXISTS conv_value;
+
+DROP TABLE IF EXISTS conversion;
+DROP TABLE IF EXISTS impression;
+
+-- +goose StatementEnd
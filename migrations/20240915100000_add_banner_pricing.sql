@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE banner
+  ADD COLUMN IF NOT EXISTS pricing TEXT NOT NULL DEFAULT 'cpm',
+  ADD COLUMN IF NOT EXISTS bid DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE banner
+  DROP COLUMN IF EXISTS pricing,
+  DROP COLUMN IF EXISTS bid;
+
+-- +goose StatementEnd
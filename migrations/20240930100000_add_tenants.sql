@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE banner ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE slot ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE social_group ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE rotation ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE statistic ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE experiment ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE impression ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE api_key ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS rotation_tenant_slot_idx ON rotation(tenant, slot);
+CREATE INDEX IF NOT EXISTS statistic_tenant_slot_idx ON statistic(tenant, slot);
+
+-- the same key may be used by clients of different tenants
+ALTER TABLE idempotency_key ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE idempotency_key DROP CONSTRAINT IF EXISTS idempotency_key_pkey;
+ALTER TABLE idempotency_key ADD PRIMARY KEY (tenant, key);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE idempotency_key DROP CONSTRAINT IF EXISTS idempotency_key_pkey;
+ALTER TABLE idempotency_key DROP COLUMN IF EXISTS tenant;
+ALTER TABLE idempotency_key ADD PRIMARY KEY (key);
+
+DROP INDEX IF EXISTS statistic_tenant_slot_idx;
+DROP INDEX IF EXISTS rotation_tenant_slot_idx;
+
+ALTER TABLE api_key DROP COLUMN IF EXISTS tenant;
+ALTER TABLE impression DROP COLUMN IF EXISTS tenant;
+ALTER TABLE experiment DROP COLUMN IF EXISTS tenant;
+ALTER TABLE statistic DROP COLUMN IF EXISTS tenant;
+ALTER TABLE rotation DROP COLUMN IF EXISTS tenant;
+ALTER TABLE social_group DROP COLUMN IF EXISTS tenant;
+ALTER TABLE slot DROP COLUMN IF EXISTS tenant;
+ALTER TABLE banner DROP COLUMN IF EXISTS tenant;
+
+-- +goose StatementEnd
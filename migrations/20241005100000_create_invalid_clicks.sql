@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS invalid_click (
+  id BIGSERIAL PRIMARY KEY,
+  tenant TEXT NOT NULL DEFAULT 'default',
+  banner INTEGER NOT NULL,
+  slot INTEGER NOT NULL,
+  s_group INTEGER NOT NULL,
+  impression BIGINT,
+  ip TEXT NOT NULL DEFAULT '',
+  user_agent TEXT NOT NULL DEFAULT '',
+  reasons TEXT[] NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS invalid_click_tenant_created_idx ON invalid_click(tenant, created_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS invalid_click;
+
+-- +goose StatementEnd